@@ -0,0 +1,130 @@
+package palettor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+)
+
+// Prune returns a new Palette without the entries whose weight is below
+// minWeight, which are often just noise. The weights of the remaining entries
+// are renormalized to sum to 1. If no entry reaches minWeight, the most
+// dominant entry is kept on its own, so that the result is never empty unless
+// p is. The receiver is not modified.
+func (p *Palette) Prune(minWeight float64) *Palette {
+	result := p.derive()
+	entries := p.EntriesBy(ByWeightDescending)
+
+	var total float64
+	var kept []Entry
+	for _, entry := range entries {
+		if entry.Weight >= minWeight {
+			kept = append(kept, entry)
+			total += entry.Weight
+		}
+	}
+	if len(kept) == 0 && len(entries) > 0 {
+		kept, total = entries[:1], entries[0].Weight
+	}
+	for _, entry := range kept {
+		weight := entry.Weight
+		if total > 0 {
+			weight /= total
+		}
+		result.add(entry.Color, weight)
+	}
+	return result
+}
+
+// Split separates the entries of a Palette into dominant colors, whose weight
+// is at least dominantWeight, and accent colors: less dominant entries that
+// are vivid enough to stand out, with an HCL chroma of at least minChroma.
+// Chroma is roughly 0 for greys and around 1 for fully saturated colors.
+//
+// Entries that are neither dominant nor accents are considered noise and are
+// omitted. Dominant entries are ordered from most to least dominant, and
+// accents from most to least vivid, then from most to least dominant.
+func (p *Palette) Split(dominantWeight, minChroma float64) (dominant, accents []Entry) {
+	return p.split(dominantWeight, minChroma, func(e Entry) float64 {
+		return entryHCL(e).c
+	})
+}
+
+// SplitBySaliency is like Split, but picks as accents the less dominant
+// entries whose pixels in img are the most visually salient, i.e. that lie in
+// detailed areas rather than flat ones, as estimated by WithSaliencyWeighting.
+// The saliency of an entry is the mean saliency of the pixels nearest to it,
+// from 0.1 for perfectly flat areas to 1, and accents have a saliency of at
+// least minSaliency. Accents are ordered from most to least salient, then from
+// most to least dominant.
+//
+// The pixels of img are interpreted in the source profile of the palette, which
+// should have been extracted from img.
+func (p *Palette) SplitBySaliency(img image.Image, dominantWeight, minSaliency float64) (dominant, accents []Entry, err error) {
+	saliency, err := p.saliency(img)
+	if err != nil {
+		return nil, nil, err
+	}
+	dominant, accents = p.split(dominantWeight, minSaliency, func(e Entry) float64 {
+		return saliency[asKey(e.Color)]
+	})
+	return dominant, accents, nil
+}
+
+// split separates the entries of a Palette into dominant entries, whose weight
+// is at least dominantWeight, and accents, the other entries whose score is at
+// least minScore, from highest to lowest score.
+func (p *Palette) split(dominantWeight, minScore float64, score func(Entry) float64) (dominant, accents []Entry) {
+	scores := make(map[rgbaKey]float64)
+	for _, entry := range p.EntriesBy(ByWeightDescending) {
+		if entry.Weight >= dominantWeight {
+			dominant = append(dominant, entry)
+			continue
+		}
+		if s := score(entry); s >= minScore {
+			accents = append(accents, entry)
+			scores[asKey(entry.Color)] = s
+		}
+	}
+	// A stable sort keeps entries of equal score from most to least dominant.
+	sort.SliceStable(accents, func(i, j int) bool {
+		return scores[asKey(accents[i].Color)] > scores[asKey(accents[j].Color)]
+	})
+	return dominant, accents
+}
+
+// saliency maps every pixel in img to its nearest entry in p and returns the
+// mean saliency of the pixels of each entry.
+func (p *Palette) saliency(img image.Image) (map[rgbaKey]float64, error) {
+	if p.Count() == 0 {
+		return nil, errors.New("cannot measure saliency of an empty palette")
+	}
+	colors, weights, err := getColors(img, newConfig([]Option{WithSourceProfile(p.profile), WithSaliencyWeighting()}))
+	if err != nil {
+		return nil, fmt.Errorf("error extracting colors from image: %w", err)
+	}
+
+	entries := p.Entries()
+	centroids := make([]hcl, len(entries))
+	index := make(map[hcl]int, len(entries))
+	for i, entry := range entries {
+		centroids[i] = entryHCL(entry)
+		index[centroids[i]] = i
+	}
+	sums := make([]float64, len(entries))
+	counts := make([]int, len(entries))
+	for i, c := range colors {
+		j := index[nearest(c, centroids)]
+		sums[j] += weights[i]
+		counts[j]++
+	}
+
+	saliency := make(map[rgbaKey]float64, len(entries))
+	for i, entry := range entries {
+		if counts[i] > 0 {
+			saliency[asKey(entry.Color)] = sums[i] / float64(counts[i])
+		}
+	}
+	return saliency, nil
+}
@@ -0,0 +1,108 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrune(t *testing.T) {
+	palette := &Palette{converged: true, iterations: 2}
+	palette.add(black, 0.6)
+	palette.add(white, 0.3)
+	palette.add(red, 0.095)
+	palette.add(darkGrey, 0.005)
+
+	pruned := palette.Prune(0.01)
+	assert.Equal(t, 3, pruned.Count())
+	assert.Equal(t, 4, palette.Count(), "original palette should not be modified")
+	assert.Equal(t, 0.0, pruned.Weight(darkGrey))
+	assert.InDelta(t, 0.6/0.995, pruned.Weight(black), 0.0001, "weights should be renormalized")
+
+	var total float64
+	for _, entry := range pruned.Entries() {
+		total += entry.Weight
+	}
+	assert.InDelta(t, 1, total, 0.0001)
+
+	// The most dominant entry survives a threshold above every weight
+	pruned = palette.Prune(1.1)
+	assert.Equal(t, 1, pruned.Count())
+	assert.Equal(t, 1.0, pruned.Weight(black))
+	assert.Equal(t, 0, (&Palette{}).Prune(0.5).Count())
+}
+
+func TestSplit(t *testing.T) {
+	dullGreen := forceHCL(color.RGBA{90, 100, 90, 255})
+
+	palette := &Palette{}
+	palette.add(black, 0.55)
+	palette.add(white, 0.3)
+	palette.add(dullGreen, 0.14)
+	palette.add(red, 0.005)
+	palette.add(blue, 0.005)
+
+	dominant, accents := palette.Split(0.2, 0.5)
+	assert.Equal(t, []Entry{{Color: black, Weight: 0.55}, {Color: white, Weight: 0.3}}, dominant)
+	assert.Equal(t, []Entry{{Color: blue, Weight: 0.005}, {Color: red, Weight: 0.005}}, accents, "accents should be sorted by chroma")
+
+	dominant, accents = palette.Split(0.1, 0.01)
+	assert.Len(t, dominant, 3)
+	assert.Len(t, accents, 2)
+
+	// Accents of equal score stay in order of dominance
+	palette.add(red, 0.004)
+	_, accents = palette.split(0.2, 0, func(Entry) float64 { return 1 })
+	assert.Equal(t, []Entry{{Color: dullGreen, Weight: 0.14}, {Color: blue, Weight: 0.005}, {Color: red, Weight: 0.004}}, accents)
+}
+
+func TestSplitBySaliency(t *testing.T) {
+	// A flat grey image with a checkerboard of red and blue 4x4 squares in one
+	// corner, and a flat green square in the opposite one
+	green := forceHCL(color.RGBA{40, 160, 60, 255})
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{128, 128, 128, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(40, 40, 64, 64), &image.Uniform{green}, image.Point{}, draw.Src)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+
+	grey := forceHCL(color.RGBA{128, 128, 128, 255})
+	palette := &Palette{}
+	palette.add(grey, 0.7969)
+	palette.add(green, 0.1406)
+	palette.add(red, 0.0312)
+	palette.add(blue, 0.0312)
+
+	// All three are vivid, but only the checkerboard is detailed
+	_, accents := palette.Split(0.5, 0.3)
+	assert.Len(t, accents, 3)
+
+	dominant, accents, err := palette.SplitBySaliency(img, 0.5, 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{{Color: grey, Weight: 0.7969}}, dominant)
+	assert.ElementsMatch(t, []Entry{{Color: red, Weight: 0.0312}, {Color: blue, Weight: 0.0312}}, accents)
+
+	_, _, err = (&Palette{}).SplitBySaliency(img, 0.5, 0.5)
+	assert.Error(t, err)
+}
+
+func TestExtractWithMinWeight(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 1))
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	img.Set(9, 0, color.White)
+
+	palette, err := Extract(2, 100, img, WithMinWeight(0.5))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, palette.Count())
+	assert.Equal(t, 1.0, palette.Entries()[0].Weight)
+}
@@ -0,0 +1,136 @@
+package palettor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Color spaces of Photoshop color swatches
+const (
+	acoRGB  = 0
+	acoHSB  = 1
+	acoCMYK = 2
+	acoLab  = 7
+	acoGray = 8
+)
+
+// EncodeACO writes the given entries, in order, as a Photoshop color swatch
+// (.aco) file. Colors are stored at 16-bit precision.
+//
+// As Photoshop itself does, the file holds a version 1 section for old
+// readers, followed by a version 2 section that adds names, which hold the
+// hex value and weight of each swatch as in EncodeASE.
+func EncodeACO(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	for _, version := range []uint16{1, 2} {
+		writeBigEndian(bw, [2]uint16{version, uint16(len(entries))})
+		for _, e := range entries {
+			c := e.RGBA64()
+			writeBigEndian(bw, [5]uint16{acoRGB, c.R, c.G, c.B, 0})
+			if version == 2 {
+				units := append(utf16.Encode([]rune(swatchName(e))), 0)
+				writeBigEndian(bw, uint32(len(units)))
+				writeBigEndian(bw, units)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeACO reads a Photoshop color swatch file, using the names in its
+// version 2 section if present. RGB, HSB, CMYK, Lab and grayscale swatches
+// are supported.
+func DecodeACO(r io.Reader) (*Palette, error) {
+	br := bufio.NewReader(r)
+	var entries []Entry
+	for section := 0; section < 2; section++ {
+		var header [2]uint16
+		err := binary.Read(br, binary.BigEndian, &header)
+		if err == io.EOF && section > 0 {
+			// A version 1 file, without names
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading ACO header: %w", err)
+		}
+		version, count := header[0], int(header[1])
+		if version != 1 && version != 2 {
+			return nil, fmt.Errorf("unsupported ACO version %d", version)
+		}
+
+		// Version 2 sections repeat the colors of the version 1 section.
+		entries = entries[:0]
+		for i := 0; i < count; i++ {
+			var values [5]uint16
+			if err := binary.Read(br, binary.BigEndian, &values); err != nil {
+				return nil, fmt.Errorf("error reading ACO color %d: %w", i, err)
+			}
+			c, err := decodeACOColor(values)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ACO color %d: %w", i, err)
+			}
+			entry := Entry{Color: fromColorful(c.Clamped())}
+			if version == 2 {
+				var n uint32
+				if err := binary.Read(br, binary.BigEndian, &n); err != nil {
+					return nil, fmt.Errorf("error reading ACO color %d: %w", i, err)
+				}
+				if n > 1<<16 {
+					return nil, fmt.Errorf("ACO color %d has too long a name", i)
+				}
+				name := make([]byte, 2*n)
+				if _, err := io.ReadFull(br, name); err != nil {
+					return nil, fmt.Errorf("error reading ACO color %d: %w", i, err)
+				}
+				entry.Name = decodeUTF16(name)
+				entry.Weight, _ = parseSwatchWeight(entry.Name)
+				entry.Name = swatchEntryName(entry.Name)
+			}
+			entries = append(entries, entry)
+		}
+		if version == 2 {
+			break
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("ACO file has no colors")
+	}
+	return paletteOf(entries), nil
+}
+
+// decodeACOColor converts the four channel values of a swatch, preceded by
+// its color space, to a color.
+func decodeACOColor(values [5]uint16) (colorful.Color, error) {
+	v := func(i int) float64 {
+		return float64(values[i+1]) / 0xffff
+	}
+	switch values[0] {
+	case acoRGB:
+		return colorful.Color{R: v(0), G: v(1), B: v(2)}, nil
+	case acoHSB:
+		return colorful.Hsv(v(0)*360, v(1), v(2)), nil
+	case acoCMYK:
+		// Channels are stored inverted, so that 0 is full ink.
+		k := v(3)
+		return colorful.Color{R: v(0) * k, G: v(1) * k, B: v(2) * k}, nil
+	case acoLab:
+		// Lightness is in the range 0-10000, and a and b in -12800-12700,
+		// stored as signed integers.
+		return colorful.Lab(
+			float64(values[1])/10000,
+			float64(int16(values[2]))/10000,
+			float64(int16(values[3]))/10000,
+		), nil
+	case acoGray:
+		// Gray is in the range 0-10000, where 10000 is black.
+		g := 1 - float64(values[1])/10000
+		return colorful.Color{R: g, G: g, B: g}, nil
+	}
+	return colorful.Color{}, fmt.Errorf("unsupported color space %d", values[0])
+}
@@ -0,0 +1,65 @@
+package palettor
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACORoundTrip(t *testing.T) {
+	palette := swatchPalette()
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeACO(&buf, palette.Entries()))
+
+	decoded, err := DecodeACO(&buf)
+	assert.NoError(t, err)
+	assertSameEntries(t, palette.Entries(), decoded.Entries(), 0)
+}
+
+func TestDecodeACOFixture(t *testing.T) {
+	// Version 1 files have no swatch names, so their colors are named after
+	// DictionaryCSS
+	for path, names := range map[string][]string{
+		"testdata/swatches.aco":    {"Ink", "Lab grey", "Pure red HSB", "Coral", "Quarter grey"},
+		"testdata/swatches-v1.aco": {"black", "gray", "red", "coral", "silver"},
+	} {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		palette, err := DecodeACO(f)
+		assert.NoError(t, err, path)
+		entries := palette.EntriesBy(ByLightness)
+		assert.Len(t, entries, 5, path)
+		for _, entry := range entries {
+			assert.InDelta(t, 0.2, entry.Weight, 1e-9)
+		}
+		assertColor(t, color.RGBA{0, 0, 0, 255}, entries[0].Color)
+		assertColor(t, color.RGBA{119, 119, 119, 255}, entries[1].Color)
+		assertColor(t, color.RGBA{255, 0, 0, 255}, entries[2].Color)
+		assertColor(t, color.RGBA{255, 128, 64, 255}, entries[3].Color)
+		assertColor(t, color.RGBA{191, 191, 191, 255}, entries[4].Color)
+		for i, entry := range entries {
+			assert.Equal(t, names[i], entry.Name, path)
+		}
+	}
+}
+
+func TestDecodeACOInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty":           "",
+		"bad version":     "\x00\x03\x00\x01",
+		"no colors":       "\x00\x01\x00\x00",
+		"truncated":       "\x00\x01\x00\x01\x00\x00\xff\xff",
+		"unknown space":   "\x00\x01\x00\x01\x00\x09\x00\x00\x00\x00\x00\x00\x00\x00",
+		"truncated names": "\x00\x01\x00\x00\x00\x02\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x05",
+	} {
+		_, err := DecodeACO(bytes.NewReader([]byte(input)))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,212 @@
+package palettor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Block types of Adobe Swatch Exchange files
+const (
+	aseGroupStart = 0xc001
+	aseGroupEnd   = 0xc002
+	aseColor      = 0x0001
+)
+
+// aseNormal is the color type of ordinary, non-global and non-spot, swatches.
+const aseNormal = 2
+
+// EncodeASE writes the given entries, in order, as an Adobe Swatch Exchange
+// (.ase) file, which can be loaded into Illustrator, Photoshop and InDesign.
+// The swatches are gathered in a group with the given name.
+//
+// Each swatch is named by its hex value and weight, e.g. "#2c487c 22.675%",
+// which DecodeASE reads back.
+func EncodeASE(w io.Writer, name string, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	block := func(kind uint16, data []byte) {
+		writeBigEndian(bw, kind)
+		writeBigEndian(bw, uint32(len(data)))
+		bw.Write(data)
+	}
+
+	bw.WriteString("ASEF")
+	writeBigEndian(bw, [2]uint16{1, 0})
+	writeBigEndian(bw, uint32(len(entries)+2))
+	block(aseGroupStart, aseString(name))
+	for _, e := range entries {
+		r, g, b := e.RGB()
+		var data bytes.Buffer
+		data.Write(aseString(swatchName(e)))
+		data.WriteString("RGB ")
+		_ = binary.Write(&data, binary.BigEndian, [3]float32{float32(r), float32(g), float32(b)})
+		_ = binary.Write(&data, binary.BigEndian, uint16(aseNormal))
+		block(aseColor, data.Bytes())
+	}
+	block(aseGroupEnd, nil)
+	return bw.Flush()
+}
+
+// DecodeASE reads an Adobe Swatch Exchange file, keeping the names of its
+// swatches. RGB, LAB, CMYK and grayscale swatches are supported, in or out of
+// groups.
+func DecodeASE(r io.Reader) (*Palette, error) {
+	var header struct {
+		Signature [4]byte
+		Version   [2]uint16
+		Count     uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("error reading ASE header: %w", err)
+	}
+	if string(header.Signature[:]) != "ASEF" {
+		return nil, errors.New("not an ASE file: missing signature")
+	}
+
+	var entries []Entry
+	for i := uint32(0); i < header.Count; i++ {
+		var block struct {
+			Kind   uint16
+			Length uint32
+		}
+		if err := binary.Read(r, binary.BigEndian, &block); err != nil {
+			return nil, fmt.Errorf("error reading ASE block %d: %w", i, err)
+		}
+		if block.Length > 1<<20 {
+			return nil, fmt.Errorf("ASE block %d is too large", i)
+		}
+		data := make([]byte, block.Length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("error reading ASE block %d: %w", i, err)
+		}
+		if block.Kind != aseColor {
+			continue
+		}
+		entry, err := decodeASEColor(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ASE color in block %d: %w", i, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("ASE file has no colors")
+	}
+	return paletteOf(entries), nil
+}
+
+func decodeASEColor(data []byte) (Entry, error) {
+	name, n, err := readASEString(data)
+	if err != nil {
+		return Entry{}, err
+	}
+	data = data[n:]
+	if len(data) < 4 {
+		return Entry{}, errors.New("missing color model")
+	}
+	model := string(data[:4])
+	channels := map[string]int{"RGB ": 3, "LAB ": 3, "CMYK": 4, "Gray": 1}[model]
+	if channels == 0 {
+		return Entry{}, fmt.Errorf("unsupported color model %q", model)
+	}
+	if len(data) < 4+4*channels {
+		return Entry{}, errors.New("truncated color values")
+	}
+	v := make([]float64, channels)
+	for i := range v {
+		v[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(data[4+4*i:])))
+	}
+
+	var c colorful.Color
+	switch model {
+	case "RGB ":
+		c = colorful.Color{R: v[0], G: v[1], B: v[2]}
+	case "LAB ":
+		// Lightness is stored as a fraction rather than a percentage, while a
+		// and b are on the usual scale of roughly ±128.
+		c = colorful.Lab(v[0], v[1]/100, v[2]/100)
+	case "CMYK":
+		k := 1 - v[3]
+		c = colorful.Color{R: (1 - v[0]) * k, G: (1 - v[1]) * k, B: (1 - v[2]) * k}
+	case "Gray":
+		c = colorful.Color{R: v[0], G: v[0], B: v[0]}
+	}
+	weight, _ := parseSwatchWeight(name)
+	return Entry{Color: fromColorful(c.Clamped()), Weight: weight, Name: swatchEntryName(name)}, nil
+}
+
+// aseString encodes a string as a length-prefixed, null terminated UTF-16
+// string.
+func aseString(s string) []byte {
+	units := append(utf16.Encode([]rune(s)), 0)
+	b := []byte{byte(len(units) >> 8), byte(len(units))}
+	for _, u := range units {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return b
+}
+
+// readASEString decodes a string encoded by aseString, returning it along with
+// the number of bytes it took up.
+func readASEString(data []byte) (string, int, error) {
+	if len(data) < 2 {
+		return "", 0, errors.New("missing name")
+	}
+	n := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+2*n {
+		return "", 0, errors.New("truncated name")
+	}
+	return decodeUTF16(data[2 : 2+2*n]), 2 + 2*n, nil
+}
+
+// decodeUTF16 decodes big-endian UTF-16, dropping any null terminator.
+func decodeUTF16(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(data[2*i:])
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
+
+// writeBigEndian writes the binary representation of v to bw in big-endian
+// byte order. Errors are sticky in a bufio.Writer, and reported by Flush.
+func writeBigEndian(bw *bufio.Writer, v interface{}) {
+	_ = binary.Write(bw, binary.BigEndian, v)
+}
+
+// swatchName names a swatch by its hex value and weight as a percentage, for
+// swatch formats that have nowhere else to keep the weight.
+func swatchName(e Entry) string {
+	return e.Hex() + " " + strconv.FormatFloat(e.Weight*100, 'g', -1, 64) + "%"
+}
+
+// swatchEntryName returns the name of a swatch as the name of its entry, or ""
+// for names written by swatchName, which only hold the color and weight.
+func swatchEntryName(name string) string {
+	fields := strings.Fields(name)
+	if _, ok := parseSwatchWeight(name); ok && len(fields) == 2 && strings.HasPrefix(fields[0], "#") {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// parseSwatchWeight reads the weight from a name written by swatchName.
+func parseSwatchWeight(name string) (float64, bool) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 || !strings.HasSuffix(fields[len(fields)-1], "%") {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "%"), 64)
+	if err != nil || percent < 0 {
+		return 0, false
+	}
+	return percent / 100, true
+}
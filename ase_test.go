@@ -0,0 +1,101 @@
+package palettor
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// swatchPalette returns a palette with colors that need more than 8 bits of
+// precision.
+func swatchPalette() *Palette {
+	palette := &Palette{}
+	palette.add(forceHCL(color.RGBA64{0xc8c8, 0x0101, 0x1234, 0xffff}), 0.16)
+	palette.add(forceHCL(color.RGBA64{0xe6e6, 0xe6e6, 0xe8e9, 0xffff}), 0.84)
+	return palette
+}
+
+// assertSameEntries checks that decoded entries match the originals, within
+// the given tolerance on 16-bit channel values.
+func assertSameEntries(t *testing.T, expected, actual []Entry, tolerance float64) {
+	t.Helper()
+	if !assert.Equal(t, len(expected), len(actual)) {
+		return
+	}
+	for i := range expected {
+		e, a := expected[i].RGBA64(), actual[i].RGBA64()
+		assert.InDelta(t, e.R, a.R, tolerance)
+		assert.InDelta(t, e.G, a.G, tolerance)
+		assert.InDelta(t, e.B, a.B, tolerance)
+		assert.InDelta(t, expected[i].Weight, actual[i].Weight, 1e-9)
+	}
+}
+
+// assertColor checks that a color matches the expected 8-bit sRGB value.
+func assertColor(t *testing.T, expected color.RGBA, actual color.Color) {
+	t.Helper()
+	c := color.RGBAModel.Convert(actual).(color.RGBA)
+	assert.InDelta(t, expected.R, c.R, 1, "red of %v", c)
+	assert.InDelta(t, expected.G, c.G, 1, "green of %v", c)
+	assert.InDelta(t, expected.B, c.B, 1, "blue of %v", c)
+}
+
+func TestASERoundTrip(t *testing.T) {
+	palette := swatchPalette()
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeASE(&buf, "Product", palette.Entries()))
+	assert.Equal(t, "ASEF", buf.String()[:4])
+
+	decoded, err := DecodeASE(&buf)
+	assert.NoError(t, err)
+	// Channels are stored as 32-bit floats
+	assertSameEntries(t, palette.Entries(), decoded.Entries(), 1)
+}
+
+func TestDecodeASEFixture(t *testing.T) {
+	f, err := os.Open("testdata/swatches.ase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	palette, err := DecodeASE(f)
+	assert.NoError(t, err)
+	// Without weights in the swatch names, colors are weighted equally
+	entries := palette.EntriesBy(ByLightness)
+	assert.Len(t, entries, 5)
+	for _, entry := range entries {
+		assert.InDelta(t, 0.2, entry.Weight, 1e-9)
+	}
+	assertColor(t, color.RGBA{0, 0, 0, 255}, entries[0].Color)
+	sky := entryHCL(entries[1])
+	assert.InDelta(t, 0.6, sky.l, 0.001)
+	assert.InDelta(t, 0.3041, sky.c, 0.001)
+	assertColor(t, color.RGBA{255, 128, 64, 255}, entries[2].Color)
+	assertColor(t, color.RGBA{204, 204, 204, 255}, entries[3].Color)
+	assertColor(t, color.RGBA{255, 255, 255, 255}, entries[4].Color)
+
+	// Swatch names are kept
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	assert.Equal(t, []string{"Ink", "Sky", "Coral", "Mist", "Paper"}, names)
+}
+
+func TestDecodeASEInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty":          "",
+		"bad signature":  "ASEX\x00\x01\x00\x00\x00\x00\x00\x00",
+		"no colors":      "ASEF\x00\x01\x00\x00\x00\x00\x00\x00",
+		"truncated":      "ASEF\x00\x01\x00\x00\x00\x00\x00\x01\x00\x01\x00\x00\x00\x10",
+		"unknown model":  "ASEF\x00\x01\x00\x00\x00\x00\x00\x01\x00\x01\x00\x00\x00\x06\x00\x00XYZ ",
+		"truncated name": "ASEF\x00\x01\x00\x00\x00\x00\x00\x01\x00\x01\x00\x00\x00\x02\x00\x05",
+	} {
+		_, err := DecodeASE(bytes.NewReader([]byte(input)))
+		assert.Error(t, err, name)
+	}
+}
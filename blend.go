@@ -0,0 +1,113 @@
+package palettor
+
+import (
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// A BlendSpace is the color space in which colors are averaged, both to find
+// the center of each cluster and to blend entries together in Palette.Merge.
+//
+// Averaging gamma-encoded colors makes mixtures look too dark: the mean of
+// black and white pixels, as seen from a distance, is much lighter than a
+// perceptual mid-grey. Averaging in linear RGB reproduces how light actually
+// mixes, while Oklab gives perceptually even blends without the hue shifts of
+// HCL.
+type BlendSpace int
+
+const (
+	// BlendHCL averages colors in HCL space, using a circular mean for hue.
+	// This is the default.
+	BlendHCL BlendSpace = iota
+	// BlendLinearRGB averages colors in linear-light RGB.
+	BlendLinearRGB
+	// BlendOklab averages colors in the Oklab perceptual color space.
+	BlendOklab
+)
+
+var blendSpaceNames = []string{
+	BlendHCL:       "hcl",
+	BlendLinearRGB: "linear",
+	BlendOklab:     "oklab",
+}
+
+// String returns the name of a BlendSpace, as accepted by ParseBlendSpace.
+func (s BlendSpace) String() string {
+	return enumString(blendSpaceNames, "BlendSpace", int(s))
+}
+
+// ParseBlendSpace returns the BlendSpace with the given name.
+func ParseBlendSpace(name string) (BlendSpace, error) {
+	i, err := parseEnum(blendSpaceNames, "blend space", name)
+	return BlendSpace(i), err
+}
+
+// mean finds the mean of the given colors in this space, each contributing in
+// proportion to its weight. A nil weights slice weights all colors equally.
+func (s BlendSpace) mean(colors []hcl, weights []float64) hcl {
+	var to func(hcl) [3]float64
+	var from func([3]float64) hcl
+	switch s {
+	case BlendLinearRGB:
+		to, from = toLinearRGB, fromLinearRGB
+	case BlendOklab:
+		to, from = toOklab, fromOklab
+	default:
+		return weightedMean(colors, weights)
+	}
+
+	var sum [3]float64
+	var total float64
+	for i, c := range colors {
+		w := weightAt(weights, i)
+		v := to(c)
+		for j := range sum {
+			sum[j] += w * v[j]
+		}
+		total += w
+	}
+	for j := range sum {
+		sum[j] /= total
+	}
+	return from(sum)
+}
+
+func toLinearRGB(c hcl) [3]float64 {
+	r, g, b := c.colorful().LinearRgb()
+	return [3]float64{r, g, b}
+}
+
+func fromLinearRGB(v [3]float64) hcl {
+	return fromColorful(colorful.LinearRgb(v[0], v[1], v[2]))
+}
+
+// toOklab converts a color to Oklab, using the matrices published at
+// https://bottosson.github.io/posts/oklab/
+func toOklab(c hcl) [3]float64 {
+	r, g, b := c.colorful().LinearRgb()
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return [3]float64{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+func fromOklab(v [3]float64) hcl {
+	return fromColorful(colorful.LinearRgb(oklabToLinearRGB(v)))
+}
+
+// oklabToLinearRGB converts an Oklab color to linear RGB, without clamping it
+// to the sRGB gamut.
+func oklabToLinearRGB(v [3]float64) (r, g, b float64) {
+	l := v[0] + 0.3963377774*v[1] + 0.2158037573*v[2]
+	m := v[0] - 0.1055613458*v[1] - 0.0638541728*v[2]
+	s := v[0] - 0.0894841775*v[1] - 1.2914855480*v[2]
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return +4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
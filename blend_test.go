@@ -0,0 +1,132 @@
+package palettor
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestBlendSpaceMean(t *testing.T) {
+	blackAndWhite := []hcl{black, white}
+	grey := func(c hcl) uint32 {
+		r, g, b, _ := c.RGBA()
+		assert.InDelta(t, r>>8, g>>8, 1, "mean should be grey")
+		assert.InDelta(t, g>>8, b>>8, 1, "mean should be grey")
+		return g >> 8
+	}
+
+	// Linear light is the physically correct mix, which is much lighter
+	// than the perceptual midpoint.
+	assert.InDelta(t, 119, grey(BlendHCL.mean(blackAndWhite, nil)), 1)
+	assert.InDelta(t, 188, grey(BlendLinearRGB.mean(blackAndWhite, nil)), 1)
+	assert.InDelta(t, 99, grey(BlendOklab.mean(blackAndWhite, nil)), 1)
+
+	for _, space := range []BlendSpace{BlendHCL, BlendLinearRGB, BlendOklab} {
+		// The mean of a single color is that color
+		c := forceHCL(color.RGBA{12, 150, 200, 255})
+		assert.Less(t, space.mean([]hcl{c}, nil).deltaE(c), 0.01, space.String())
+
+		// Weights are respected
+		assert.Less(t, space.mean([]hcl{red, blue}, []float64{1, 0}).deltaE(red), 0.01, space.String())
+	}
+}
+
+func TestOklabRoundTrip(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		c := forceHCL(randomColor().Clamped())
+		assert.Less(t, fromOklab(toOklab(c)).deltaE(c), 0.001)
+	}
+
+	// Reference values from https://bottosson.github.io/posts/oklab/
+	lab := toOklab(white)
+	assert.InDelta(t, 1, lab[0], 0.001)
+	assert.InDelta(t, 0, lab[1], 0.001)
+	assert.InDelta(t, 0, lab[2], 0.001)
+}
+
+func TestExtractWithBlendSpace(t *testing.T) {
+	palette, err := Extract(1, 100, halvesImage(), WithBlendSpace(BlendLinearRGB))
+	assert.NoError(t, err)
+	assert.Equal(t, BlendLinearRGB, palette.BlendSpace())
+
+	merged := palette.Merge(5)
+	assert.Equal(t, BlendLinearRGB, merged.BlendSpace(), "merged palettes should keep their blend space")
+}
+
+// blendPairs are the pairs of colors blended in the golden images.
+var blendPairs = [][2]color.RGBA{
+	{{0, 0, 0, 255}, {255, 255, 255, 255}},
+	{{255, 0, 0, 255}, {0, 255, 0, 255}},
+	{{0, 0, 255, 255}, {255, 255, 0, 255}},
+	{{255, 0, 255, 255}, {0, 255, 255, 255}},
+	{{200, 30, 40, 255}, {20, 40, 160, 255}},
+}
+
+// renderBlends draws one row per pair of colors: the first color, the mean of
+// both colors in the given space, and the second color.
+func renderBlends(space BlendSpace) *image.RGBA {
+	const size = 32
+	img := image.NewRGBA(image.Rect(0, 0, 3*size, len(blendPairs)*size))
+	for row, pair := range blendPairs {
+		a, b := forceHCL(pair[0]), forceHCL(pair[1])
+		for col, c := range []color.Color{pair[0], space.mean([]hcl{a, b}, nil), pair[1]} {
+			rect := image.Rect(col*size, row*size, (col+1)*size, (row+1)*size)
+			draw.Draw(img, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+		}
+	}
+	return img
+}
+
+// TestBlendGolden documents the visible difference between blend spaces with
+// golden images in testdata/blend-*.png. Run with -update to regenerate them.
+func TestBlendGolden(t *testing.T) {
+	for _, space := range []BlendSpace{BlendHCL, BlendLinearRGB, BlendOklab} {
+		path := filepath.Join("testdata", "blend-"+space.String()+".png")
+		actual := renderBlends(space)
+
+		if *update {
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := png.Encode(f, actual); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("missing golden image, run with -update: %s", err)
+		}
+		golden, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, golden.Bounds(), actual.Bounds())
+		bounds := golden.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				// Allow for floating point differences across platforms
+				gr, gg, gb, _ := golden.At(x, y).RGBA()
+				ar, ag, ab, _ := actual.At(x, y).RGBA()
+				if !assert.InDelta(t, gr>>8, ar>>8, 1) ||
+					!assert.InDelta(t, gg>>8, ag>>8, 1) ||
+					!assert.InDelta(t, gb>>8, ab>>8, 1) {
+					t.Fatalf("%s differs from golden image at (%d, %d)", path, x, y)
+				}
+			}
+		}
+	}
+}
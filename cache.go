@@ -0,0 +1,121 @@
+package palettor
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A Cache memoizes the conversion of pixel colors to the color space used for
+// clustering, which dominates the cost of reading an image. Images rarely
+// contain more than a few thousand distinct colors, so a Cache shared across
+// Extract calls lets batch jobs over similar images skip most conversions.
+//
+// A Cache holds a bounded number of colors and is safe for concurrent use by
+// multiple goroutines.
+type Cache struct {
+	// Accessed atomically; kept first for 64-bit alignment on 32-bit
+	// platforms.
+	hits, misses uint64
+
+	mu      sync.RWMutex
+	size    int
+	entries map[cacheKey]hcl
+}
+
+// cacheKey identifies a cached conversion. The same pixel values stand for
+// different colors in different profiles.
+type cacheKey struct {
+	rgba    rgbaKey
+	profile *Profile
+}
+
+// CacheStats reports on the effectiveness of a Cache.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate returns the share of lookups, as a float in the range [0, 1], that
+// were served from the cache.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// NewCache creates a Cache holding at most size colors. When it is full, an
+// arbitrary color is evicted to make room for each new one.
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{
+		size:    size,
+		entries: make(map[cacheKey]hcl, size),
+	}
+}
+
+// Stats returns the number of cache hits and misses so far, along with the
+// number of colors currently cached.
+func (c *Cache) Stats() CacheStats {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+	return CacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: entries,
+	}
+}
+
+// convert is a memoized equivalent of rgbaToHCL.
+func (c *Cache) convert(r, g, b, a uint32) (hcl, bool) {
+	return c.lookup(r, g, b, a, nil)
+}
+
+// converter returns a memoized converter for pixels encoded in the given
+// profile, where nil means sRGB.
+func (c *Cache) converter(profile *Profile) converter {
+	if profile.isSRGB() {
+		return c.convert
+	}
+	return func(r, g, b, a uint32) (hcl, bool) {
+		return c.lookup(r, g, b, a, profile)
+	}
+}
+
+func (c *Cache) lookup(r, g, b, a uint32, profile *Profile) (hcl, bool) {
+	if a == 0 {
+		return hcl{}, false
+	}
+	key := cacheKey{rgbaKey{r, g, b, a}, profile}
+
+	c.mu.RLock()
+	result, found := c.entries[key]
+	c.mu.RUnlock()
+	if found {
+		atomic.AddUint64(&c.hits, 1)
+		return result, true
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	if profile == nil {
+		result, _ = rgbaToHCL(r, g, b, a)
+	} else {
+		result, _ = profile.convert(r, g, b, a)
+	}
+
+	c.mu.Lock()
+	if _, found := c.entries[key]; !found && len(c.entries) >= c.size {
+		for evicted := range c.entries {
+			delete(c.entries, evicted)
+			break
+		}
+	}
+	c.entries[key] = result
+	c.mu.Unlock()
+	return result, true
+}
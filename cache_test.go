@@ -0,0 +1,76 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+	assert.Equal(t, 0.0, cache.Stats().HitRate())
+
+	for _, c := range []color.RGBA{
+		{255, 0, 0, 255},
+		{255, 0, 0, 255},
+		{0, 255, 0, 255},
+		{255, 0, 0, 255},
+	} {
+		expected, _ := rgbaToHCL(c.RGBA())
+		actual, ok := cache.convert(c.RGBA())
+		assert.True(t, ok)
+		assert.Equal(t, expected, actual, "cached conversion should match uncached conversion")
+	}
+	stats := cache.Stats()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Entries: 2}, stats)
+	assert.Equal(t, 0.5, stats.HitRate())
+
+	// The cache is bounded
+	cache.convert(color.RGBA{0, 0, 255, 255}.RGBA())
+	assert.Equal(t, 2, cache.Stats().Entries)
+
+	// Transparent colors are neither converted nor cached
+	_, ok := cache.convert(color.Transparent.RGBA())
+	assert.False(t, ok)
+	assert.Equal(t, uint64(3), cache.Stats().Misses)
+
+	assert.Equal(t, 1, NewCache(0).size, "cache size should be at least 1")
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	cache := NewCache(64)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				cache.convert(color.Gray{uint8(j % 100)}.RGBA())
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(8000), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Entries, 64)
+}
+
+func TestExtractWithCache(t *testing.T) {
+	img := halvesImage()
+	cache := NewCache(100)
+
+	for i := 0; i < 3; i++ {
+		palette, err := Extract(1, 100, img, WithCache(cache), WithRegion(image.Rect(0, 0, 2, 2)))
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, palette.Weight(red))
+	}
+
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(11), stats.Hits)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
@@ -10,10 +12,15 @@ import (
 	"image/jpeg"
 	"image/png"
 	"io"
+	"io/ioutil"
 	"log"
 	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/lucasb-eyer/go-colorful"
 	"github.com/mccutchen/palettor"
 	"github.com/nfnt/resize"
 	"github.com/pkg/profile"
@@ -23,8 +30,28 @@ func main() {
 	var (
 		k          = flag.Int("k", 3, "Palette size")
 		maxIters   = flag.Int("max", 500, "Maximum k-means iterations")
-		jsonOutput = flag.Bool("json", false, "Output color palette in JSON format")
-		noResize   = flag.Bool("no-resize", false, "Do not resize input image before processing")
+		jsonOutput = flag.Bool("json", false, "Output color palette in JSON format (same as -format json)")
+		outFormat  = flag.String("format", "", "Output format: terminal, image, json, palette, gpl, ase, aco, css, scss, tailwind, tokens, theme, svg, or html (default terminal if stdout is a terminal, otherwise image)")
+		thumbnail  = flag.Bool("thumbnail", false, "Draw a thumbnail of the image above the palette in terminal output")
+		noResize   = flag.Bool("no-resize", false, "Consider every pixel of the image, and draw image output at full size (same as -sample all)")
+		sample     = flag.String("sample", "random:40000", "Sample pixels from the image: grid:STRIDE, random:N, tiles:T:N, or all")
+		quality    = flag.Bool("quality", false, "Report palette quality metrics on stderr")
+		blend      = flag.String("blend", "hcl", "Color space for averaging colors: hcl, linear, or oklab")
+		merge      = flag.Float64("merge", 0, "Merge palette entries closer than this CIEDE2000 ΔE")
+		minWeight  = flag.Float64("min-weight", 0, "Drop palette entries with less than this weight")
+		exclude    = flag.String("exclude", "", "Comma-separated hex colors to exclude before clustering")
+		excludeBg  = flag.Bool("exclude-background", false, "Exclude the background color detected from the image border")
+		excludeTol = flag.Float64("exclude-tolerance", 5, "CIEDE2000 ΔE tolerance for excluded colors")
+		crop       = flag.String("crop", "", "Only extract colors from the region x,y,w,h of the input image")
+		maskPath   = flag.String("mask", "", "Weight pixels by the alpha (or grey level) of this mask image")
+		centerBias = flag.Float64("center-weight", 0, "Weight pixels towards the center with a Gaussian of this relative standard deviation")
+		saliency   = flag.Bool("saliency", false, "Weight pixels by their estimated visual saliency")
+		sortOrder  = flag.String("sort", "weight", "Palette order: weight, weight-desc, hue, lightness, chroma, or smooth")
+		naming     = flag.String("naming", "index", "Naming of colors in css, scss, tailwind and tokens output: index, rank, or color")
+		dictPath   = flag.String("dictionary", "css", "Name colors after this dictionary: css, none, or the path to a list of named hex colors like https://xkcd.com/color/rgb.txt")
+		scaleSteps = flag.Int("scale", 0, "Output a tonal scale of this many steps for each color, in terminal, json, css, scss, tailwind and tokens output")
+		scaleSpace = flag.String("scale-space", "hcl", "Color space for tonal scales: hcl or oklch")
+		ignoreICC  = flag.Bool("ignore-icc", false, "Treat the input image as sRGB, ignoring any embedded ICC color profile")
 		doProfile  = flag.Bool("profile", false, "Capture profile")
 	)
 	flag.Usage = func() {
@@ -47,14 +74,136 @@ func main() {
 		}
 	}
 
-	img, format, err := loadImage(input)
+	if *jsonOutput {
+		*outFormat = "json"
+	}
+	if *outFormat == "" {
+		// Don't make a mess of the terminal with binary image data
+		*outFormat = "image"
+		if isTerminal(os.Stdout) {
+			*outFormat = "terminal"
+		}
+	}
+	switch *outFormat {
+	case "terminal", "image", "json", "palette", "gpl", "ase", "aco", "css", "scss", "tailwind", "tokens", "theme", "svg", "html":
+	default:
+		log.Fatalf("Invalid output format: %q", *outFormat)
+	}
+
+	order, err := palettor.ParseOrder(*sortOrder)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	toneSpace, err := palettor.ParseScaleSpace(*scaleSpace)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *scaleSteps > palettor.MaxScaleSteps {
+		log.Fatalf("Tonal scales have at most %d steps", palettor.MaxScaleSteps)
+	}
+	if *scaleSteps > 0 {
+		switch *outFormat {
+		case "terminal", "json", "css", "scss", "tailwind", "tokens":
+		default:
+			log.Fatalf("Tonal scales are not supported in %s output", *outFormat)
+		}
+	}
+
+	colorNaming, err := palettor.ParseNaming(*naming)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blendSpace, err := palettor.ParseBlendSpace(*blend)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := []palettor.Option{
+		palettor.WithBlendSpace(blendSpace),
+		palettor.WithMergeThreshold(*merge),
+		palettor.WithMinWeight(*minWeight),
+	}
+	if *exclude != "" {
+		for _, hex := range strings.Split(*exclude, ",") {
+			c, err := colorful.Hex(strings.TrimSpace(hex))
+			if err != nil {
+				log.Fatalf("Invalid excluded color: %s", err)
+			}
+			opts = append(opts, palettor.WithExclusion(c, *excludeTol))
+		}
+	}
+	if *excludeBg {
+		opts = append(opts, palettor.WithBackgroundExclusion(*excludeTol))
+	}
+	if *centerBias < 0 || math.IsNaN(*centerBias) {
+		log.Fatalf("Invalid center weight: %v", *centerBias)
+	}
+	if *centerBias > 0 {
+		opts = append(opts, palettor.WithCenterWeighting(*centerBias))
+	}
+	if *saliency {
+		opts = append(opts, palettor.WithSaliencyWeighting())
+	}
+
+	dictionary, err := loadDictionary(*dictPath)
+	if err != nil {
+		log.Fatalf("Error loading dictionary: %s", err)
+	}
+	opts = append(opts, palettor.WithDictionary(dictionary))
+
+	if *noResize {
+		*sample = "all"
+	}
+	sampling, err := parseSampling(*sample)
+	if err != nil {
+		log.Fatalf("Invalid sampling: %s", err)
+	}
+	if sampling != nil {
+		opts = append(opts, sampling)
+	}
+
+	var region image.Rectangle
+	if *crop != "" {
+		region, err = parseRect(*crop)
+		if err != nil {
+			log.Fatalf("Invalid crop: %s", err)
+		}
+	}
+
+	var mask image.Image
+	if *maskPath != "" {
+		mask, err = loadMask(*maskPath)
+		if err != nil {
+			log.Fatalf("Error loading mask: %s", err)
+		}
+	}
+
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		log.Fatalf("Error reading image: %s", err)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		log.Fatalf("Error decoding image: %s", err)
 	}
+	if !*ignoreICC {
+		sourceProfile, err := palettor.ReadProfile(bytes.NewReader(data))
+		if err != nil {
+			// Unsupported profiles, e.g. of greyscale or CMYK images, are no
+			// reason to give up on the image.
+			log.Printf("Warning: ignoring color profile: %s", err)
+			sourceProfile = palettor.ProfileSRGB
+		}
+		opts = append(opts, palettor.WithSourceProfile(sourceProfile))
+	}
 
-	// Get the image down to a more manageable size
-	if !*noResize {
-		img = resize.Thumbnail(200, 200, img, resize.NearestNeighbor)
+	if *crop != "" {
+		opts = append(opts, palettor.WithRegion(region))
+	}
+	if mask != nil {
+		opts = append(opts, palettor.WithMask(mask))
 	}
 
 	// Only start profiling after the image is loaded
@@ -62,61 +211,218 @@ func main() {
 		defer profile.Start().Stop()
 	}
 
-	palette, err := palettor.Extract(*k, *maxIters, img)
+	palette, err := palettor.Extract(*k, *maxIters, img, opts...)
 	if err != nil {
 		log.Fatalf("Error extracting color palette: %s", err)
 	}
 
-	if *jsonOutput {
-		if err := json.NewEncoder(os.Stdout).Encode(palette.Entries()); err != nil {
-			log.Fatalf("Error encoding JSON: %s", err)
+	if *quality {
+		report, err := palette.Quality(img)
+		if err != nil {
+			log.Fatalf("Error measuring palette quality: %s", err)
+		}
+		fmt.Fprintln(os.Stderr, report)
+	}
+
+	entries := palette.EntriesBy(order)
+
+	if *scaleSteps > 0 {
+		err = writeScales(os.Stdout, img, entries, palettor.Scales(entries, *scaleSteps, toneSpace), palette.SourceProfile(), *outFormat, colorNaming, *thumbnail)
+		if err != nil {
+			log.Fatalf("Error encoding tonal scales: %s", err)
 		}
 		return
 	}
 
-	if err := drawPalette(os.Stdout, img, palette, format); err != nil {
+	switch *outFormat {
+	case "terminal":
+		err = drawTerminal(os.Stdout, img, entries, nil, palette.SourceProfile(), *thumbnail, supportsTruecolor())
+	case "json":
+		err = json.NewEncoder(os.Stdout).Encode(entries)
+	case "palette":
+		err = json.NewEncoder(os.Stdout).Encode(palette)
+	case "gpl":
+		err = palettor.EncodeGPL(os.Stdout, paletteName(inputPath), entries)
+	case "ase":
+		err = palettor.EncodeASE(os.Stdout, paletteName(inputPath), entries)
+	case "aco":
+		err = palettor.EncodeACO(os.Stdout, entries)
+	case "css":
+		err = palettor.EncodeCSS(os.Stdout, entries, colorNaming)
+	case "scss":
+		err = palettor.EncodeSCSS(os.Stdout, entries, colorNaming)
+	case "tailwind":
+		err = palettor.EncodeTailwind(os.Stdout, entries, colorNaming)
+	case "tokens":
+		err = palettor.EncodeDesignTokens(os.Stdout, entries, colorNaming)
+	case "theme":
+		err = palettor.EncodeThemeCSS(os.Stdout, palette.Roles(), palette.LightTheme(), palette.DarkTheme())
+	case "svg":
+		err = palettor.EncodeSVG(os.Stdout, paletteName(inputPath), entries, resize.Thumbnail(200, 200, img, resize.Bilinear))
+	case "html":
+		err = palettor.EncodeHTML(os.Stdout, paletteName(inputPath), entries, resize.Thumbnail(200, 200, img, resize.Bilinear))
+	default:
+		// Palettes are drawn over a thumbnail, unless asked otherwise.
+		drawn := img
+		if !*noResize {
+			drawn = resize.Thumbnail(200, 200, img, resize.NearestNeighbor)
+		}
+		err = drawPalette(os.Stdout, drawn, entries, palette.SourceProfile(), format)
+	}
+	if err != nil {
 		log.Fatalf("Error encoding palette: %s", err)
 	}
 }
 
-func loadImage(src io.Reader) (image.Image, string, error) {
-	img, format, err := image.Decode(src)
+// Write the tonal scales of palette entries in one of the output formats that
+// support them
+func writeScales(dst io.Writer, img image.Image, entries []palettor.Entry, scales []palettor.Scale, profile *palettor.Profile, format string, naming palettor.Naming, thumbnail bool) error {
+	switch format {
+	case "terminal":
+		return drawTerminal(dst, img, entries, scales, profile, thumbnail, supportsTruecolor())
+	case "json":
+		return json.NewEncoder(dst).Encode(scales)
+	case "css":
+		return palettor.EncodeScalesCSS(dst, scales, naming)
+	case "scss":
+		return palettor.EncodeScalesSCSS(dst, scales, naming)
+	case "tailwind":
+		return palettor.EncodeScalesTailwind(dst, scales, naming)
+	case "tokens":
+		return palettor.EncodeScalesDesignTokens(dst, scales, naming)
+	}
+	return fmt.Errorf("unsupported format: %q", format)
+}
+
+// Name a palette after the file it was extracted from
+func paletteName(inputPath string) string {
+	if inputPath == "" || inputPath == "-" {
+		return "palettor"
+	}
+	base := filepath.Base(inputPath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Parse a sampling strategy given as grid:STRIDE, random:N or tiles:T:N, or
+// "all" for no sampling, in which case the returned option is nil
+func parseSampling(s string) (palettor.Option, error) {
+	if s == "all" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ":")
+	args := make([]int, len(parts)-1)
+	for i, part := range parts[1:] {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("expected positive integer arguments, got %q", s)
+		}
+		args[i] = v
+	}
+	switch {
+	case parts[0] == "grid" && len(args) == 1:
+		return palettor.WithGridSampling(args[0]), nil
+	case parts[0] == "random" && len(args) == 1:
+		return palettor.WithRandomSampling(args[0]), nil
+	case parts[0] == "tiles" && len(args) == 2:
+		return palettor.WithTileSampling(args[0], args[1]), nil
+	}
+	return nil, fmt.Errorf("expected grid:STRIDE, random:N, tiles:T:N or all, got %q", s)
+}
+
+// Parse a rectangle given as x,y,w,h
+func parseRect(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("expected x,y,w,h, got %q", s)
+	}
+	var values [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("expected x,y,w,h, got %q", s)
+		}
+		values[i] = v
+	}
+	x, y, w, h := values[0], values[1], values[2], values[3]
+	if w <= 0 || h <= 0 {
+		return image.Rectangle{}, fmt.Errorf("width and height must be positive, got %q", s)
+	}
+	return image.Rect(x, y, x+w, y+h), nil
+}
+
+// Load a color dictionary from a file, unless it's one of the special names
+// "css" or "none"
+func loadDictionary(path string) (*palettor.Dictionary, error) {
+	switch path {
+	case "css":
+		return palettor.DictionaryCSS, nil
+	case "none", "":
+		return nil, nil
+	case "xkcd":
+		return nil, errors.New("the XKCD color survey is not built in: download https://xkcd.com/color/rgb.txt and pass its path")
+	}
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
+	defer f.Close()
 
-	// Ensure we're working with RGBA data, which is necessary to a) have more
-	// immediately useful JSON output and b) allow us to draw a palette back
-	// onto the source image.
-	//
-	// In particular, JPEGs decode to *image.YCbCr, which must be converted to
-	// *image.RGBA before we can draw our palette onto it.
-	//
-	// https://stackoverflow.com/a/47539710/151221
-	if _, ok := img.(*image.RGBA); !ok {
-		img2 := image.NewRGBA(img.Bounds())
-		draw.Draw(img2, img2.Bounds(), img, image.Point{}, draw.Src)
-		img = img2
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return palettor.ReadDictionary(name, f)
+}
+
+// Load a mask image. Masks are applied by their alpha channel, so greyscale
+// masks, which are always opaque, have their grey levels reinterpreted as
+// alpha.
+func loadMask(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
-	return img, format, nil
+	mask, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	switch m := mask.(type) {
+	case *image.Gray:
+		return &image.Alpha{Pix: m.Pix, Stride: m.Stride, Rect: m.Rect}, nil
+	case *image.Gray16:
+		return &image.Alpha16{Pix: m.Pix, Stride: m.Stride, Rect: m.Rect}, nil
+	}
+	return mask, nil
 }
 
-// Draw a palette over the bottom 10% of an image
-func drawPalette(dst io.Writer, img image.Image, palette *palettor.Palette, format string) error {
-	drawImg := img.(draw.Image)
+// Draw palette entries, in order, over the bottom 10% of an image. The colors
+// are encoded in the image's own color profile, so that they match its pixels.
+func drawPalette(dst io.Writer, img image.Image, entries []palettor.Entry, profile *palettor.Profile, format string) error {
+	// The image is drawn on as is if it holds RGB pixels, which is the case of
+	// most PNGs. Others, like the YCbCr data of JPEGs or the limited colors
+	// of paletted images, are converted to 16-bit RGBA first.
+	//
+	// https://stackoverflow.com/a/47539710/151221
+	var drawImg draw.Image
+	switch img := img.(type) {
+	case *image.RGBA, *image.RGBA64, *image.NRGBA, *image.NRGBA64:
+		drawImg = img.(draw.Image)
+	default:
+		drawImg = image.NewRGBA64(img.Bounds())
+		draw.Draw(drawImg, drawImg.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
 
-	imgWidth := img.Bounds().Dx()
-	imgHeight := img.Bounds().Dy()
+	imgBounds := drawImg.Bounds()
+	imgWidth := imgBounds.Dx()
+	imgHeight := imgBounds.Dy()
 
 	paletteHeight := int(math.Ceil(float64(imgHeight) * 0.1))
-	yOffset := imgHeight - paletteHeight
-	xOffset := 0
+	yOffset := imgBounds.Max.Y - paletteHeight
+	xOffset := imgBounds.Min.X
 
-	for _, entry := range palette.Entries() {
+	for _, entry := range entries {
 		colorWidth := int(math.Ceil(float64(imgWidth) * entry.Weight))
 		bounds := image.Rect(xOffset, yOffset, xOffset+colorWidth, yOffset+paletteHeight)
-		draw.Draw(drawImg, bounds, &image.Uniform{entry.Color}, image.Point{}, draw.Src)
+		draw.Draw(drawImg, bounds, &image.Uniform{profile.Encode(entry.Color)}, image.Point{}, draw.Src)
 		xOffset += colorWidth
 	}
 
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mccutchen/palettor"
+	"github.com/nfnt/resize"
+)
+
+// Width, in columns, of terminal thumbnails and color blocks
+const (
+	thumbnailColumns = 48
+	blockColumns     = 8
+)
+
+// Whether a file is an interactive terminal rather than a pipe or a file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Whether the terminal supports 24-bit color, as advertised by COLORTERM.
+// Other terminals are assumed to support the 256-color palette.
+func supportsTruecolor() bool {
+	colorterm := os.Getenv("COLORTERM")
+	return colorterm == "truecolor" || colorterm == "24bit"
+}
+
+// An ansiPalette renders colors as ANSI escape sequences
+type ansiPalette struct {
+	truecolor bool
+}
+
+// The escape sequence setting the foreground (38) or background (48) color
+func (p ansiPalette) sequence(layer int, c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	r, g, b = r>>8, g>>8, b>>8
+	if p.truecolor {
+		return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, r, g, b)
+	}
+	return fmt.Sprintf("\x1b[%d;5;%dm", layer, xterm256(r, g, b))
+}
+
+func (p ansiPalette) fg(c color.Color) string { return p.sequence(38, c) }
+func (p ansiPalette) bg(c color.Color) string { return p.sequence(48, c) }
+
+const ansiReset = "\x1b[0m"
+
+// The levels of each channel in the 6x6x6 color cube of the xterm 256-color
+// palette
+var cubeLevels = [6]uint32{0, 95, 135, 175, 215, 255}
+
+// Find the closest color in the xterm 256-color palette, considering the
+// color cube (16-231) and the greyscale ramp (232-255)
+func xterm256(r, g, b uint32) int {
+	nearestLevel := func(v uint32) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if absDiff(v, level) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := distance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// Grey levels run from 8 to 238 in steps of 10
+	mean := (r + g + b) / 3
+	greyIndex := 0
+	if mean > 8 {
+		greyIndex = int((mean - 8 + 5) / 10)
+	}
+	if greyIndex > 23 {
+		greyIndex = 23
+	}
+	grey := uint32(8 + 10*greyIndex)
+	if distance(r, g, b, grey, grey, grey) < cubeDist {
+		return 232 + greyIndex
+	}
+	return cube
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func distance(r1, g1, b1, r2, g2, b2 uint32) uint32 {
+	dr, dg, db := absDiff(r1, r2), absDiff(g1, g2), absDiff(b1, b2)
+	return dr*dr + dg*dg + db*db
+}
+
+// Render palette entries as blocks of color followed by their hex value,
+// weight and name, optionally below a thumbnail of the image drawn with half
+// blocks. If scales are given, each entry is followed by its tonal scale.
+func drawTerminal(dst io.Writer, img image.Image, entries []palettor.Entry, scales []palettor.Scale, profile *palettor.Profile, thumbnail, truecolor bool) error {
+	p := ansiPalette{truecolor: truecolor}
+	w := bufio.NewWriter(dst)
+
+	if thumbnail {
+		drawThumbnail(w, p, img)
+		w.WriteString("\n")
+	}
+	for i, entry := range entries {
+		c := profile.Encode(entry.Color)
+		fmt.Fprintf(w, "%s%s%s  %s  %6.2f%%",
+			p.bg(c), strings.Repeat(" ", blockColumns), ansiReset, entry.Hex(), entry.Weight*100)
+		if entry.Name != "" {
+			w.WriteString("  " + entry.Name)
+		}
+		w.WriteString("\n")
+		if scales != nil {
+			drawScale(w, p, scales[i], profile)
+		}
+	}
+	return w.Flush()
+}
+
+// Draw the tones of a scale as a row of blocks, each labeled with its step in
+// black or white, whichever is more legible
+func drawScale(w *bufio.Writer, p ansiPalette, scale palettor.Scale, profile *palettor.Profile) {
+	for _, tone := range scale.Tones {
+		label := color.Color(color.Black)
+		if palettor.ContrastRatio(tone.Color, color.White) > palettor.ContrastRatio(tone.Color, color.Black) {
+			label = color.White
+		}
+		fmt.Fprintf(w, "%s%s%5d ", p.bg(profile.Encode(tone.Color)), p.fg(label), tone.Step)
+	}
+	w.WriteString(ansiReset + "\n")
+}
+
+// Draw an image with the upper half block character, using the foreground
+// color for the upper pixel and the background color for the lower one, so
+// that each character cell shows two pixels
+func drawThumbnail(w *bufio.Writer, p ansiPalette, img image.Image) {
+	bounds := img.Bounds()
+	// Terminal cells are about twice as tall as they are wide, which the half
+	// blocks make up for.
+	columns := thumbnailColumns
+	if bounds.Dx() < columns {
+		columns = bounds.Dx()
+	}
+	rows := columns * bounds.Dy() / bounds.Dx()
+	small := resize.Resize(uint(columns), uint(rows), img, resize.Bilinear)
+	sb := small.Bounds()
+
+	for y := sb.Min.Y; y < sb.Max.Y; y += 2 {
+		for x := sb.Min.X; x < sb.Max.X; x++ {
+			w.WriteString(p.fg(small.At(x, y)))
+			if y+1 < sb.Max.Y {
+				w.WriteString(p.bg(small.At(x, y+1)))
+			}
+			w.WriteString("▀")
+		}
+		w.WriteString(ansiReset + "\n")
+	}
+}
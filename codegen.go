@@ -0,0 +1,268 @@
+package palettor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A Naming is a strategy for naming palette entries in generated code, such
+// as CSS custom properties or design tokens.
+type Naming int
+
+const (
+	// NameByIndex names entries by their position, starting from 1, e.g.
+	// "color-1".
+	NameByIndex Naming = iota
+	// NameByRank names entries by the rank of their weight, where 1 is the
+	// most dominant color, e.g. "rank-1".
+	NameByRank
+	// NameByColor names entries after their Entry.Name, or the nearest CSS
+	// named color if they have none, with any spaces turned into hyphens,
+	// e.g. "steelblue" or "dusty-rose". Entries that share a name are
+	// numbered, e.g. "steelblue-2".
+	NameByColor
+)
+
+var namingNames = []string{
+	NameByIndex: "index",
+	NameByRank:  "rank",
+	NameByColor: "color",
+}
+
+// String returns the name of a Naming, as accepted by ParseNaming.
+func (n Naming) String() string {
+	return enumString(namingNames, "Naming", int(n))
+}
+
+// ParseNaming returns the Naming with the given name.
+func ParseNaming(name string) (Naming, error) {
+	i, err := parseEnum(namingNames, "naming", name)
+	return Naming(i), err
+}
+
+// names returns a distinct name for each of the given entries.
+func (n Naming) names(entries []Entry) []string {
+	names := make([]string, len(entries))
+	switch n {
+	case NameByRank:
+		ranked := make([]int, len(entries))
+		for i := range ranked {
+			ranked[i] = i
+		}
+		sort.SliceStable(ranked, func(a, b int) bool {
+			return entries[ranked[a]].Weight > entries[ranked[b]].Weight
+		})
+		for rank, i := range ranked {
+			names[i] = "rank-" + strconv.Itoa(rank+1)
+		}
+	case NameByColor:
+		seen := make(map[string]int)
+		for i, e := range entries {
+			name := identifier(entryName(e))
+			seen[name]++
+			if seen[name] > 1 {
+				name += "-" + strconv.Itoa(seen[name])
+			}
+			names[i] = name
+		}
+	default:
+		for i := range entries {
+			names[i] = "color-" + strconv.Itoa(i+1)
+		}
+	}
+	return names
+}
+
+// identifier turns a color name into a lowercase identifier, joining its words
+// with hyphens.
+func identifier(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+}
+
+// weightComment describes the weight of an entry as a percentage.
+func weightComment(e Entry) string {
+	return strconv.FormatFloat(e.Weight*100, 'f', 2, 64) + "%"
+}
+
+// EncodeCSS writes the given entries, in order, as CSS custom properties on
+// the :root element, e.g. "--color-1: #2c487c;".
+func EncodeCSS(w io.Writer, entries []Entry, naming Naming) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(":root {\n")
+	for i, name := range naming.names(entries) {
+		fmt.Fprintf(bw, "  --%s: %s; /* %s */\n", name, entries[i].Hex(), weightComment(entries[i]))
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// EncodeSCSS writes the given entries, in order, as SCSS variables, e.g.
+// "$color-1: #2c487c;".
+func EncodeSCSS(w io.Writer, entries []Entry, naming Naming) error {
+	bw := bufio.NewWriter(w)
+	for i, name := range naming.names(entries) {
+		fmt.Fprintf(bw, "$%s: %s; // %s\n", name, entries[i].Hex(), weightComment(entries[i]))
+	}
+	return bw.Flush()
+}
+
+// EncodeTailwind writes the given entries, in order, as a Tailwind CSS
+// configuration module that sets theme.colors. The configuration object is
+// plain JSON, so it can also be extracted for other tools.
+func EncodeTailwind(w io.Writer, entries []Entry, naming Naming) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("module.exports = {\n  \"theme\": {\n    \"colors\": {\n")
+	for i, name := range naming.names(entries) {
+		fmt.Fprintf(bw, "      %s: %s", jsonString(name), jsonString(entries[i].Hex()))
+		if i < len(entries)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("    }\n  }\n};\n")
+	return bw.Flush()
+}
+
+// EncodeDesignTokens writes the given entries, in order, as color tokens in
+// the W3C Design Tokens Community Group format. The weight of each entry is
+// recorded in its description and, as a number, in its extensions.
+func EncodeDesignTokens(w io.Writer, entries []Entry, naming Naming) error {
+	type token struct {
+		Type        string                        `json:"$type"`
+		Value       string                        `json:"$value"`
+		Description string                        `json:"$description"`
+		Extensions  map[string]map[string]float64 `json:"$extensions"`
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{\n")
+	// Write the tokens one by one, since encoding a map would sort them by
+	// name rather than keep them in order.
+	for i, name := range naming.names(entries) {
+		data, err := json.MarshalIndent(token{
+			Type:        "color",
+			Value:       entries[i].Hex(),
+			Description: weightComment(entries[i]) + " of the image",
+			Extensions: map[string]map[string]float64{
+				"com.github.mccutchen.palettor": {"weight": entries[i].Weight},
+			},
+		}, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "  %s: %s", jsonString(name), data)
+		if i < len(entries)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// scaleNames names the entries of the given scales.
+func (n Naming) scaleNames(scales []Scale) []string {
+	entries := make([]Entry, len(scales))
+	for i, scale := range scales {
+		entries[i] = scale.Entry
+	}
+	return n.names(entries)
+}
+
+// EncodeScalesCSS writes the tones of the given scales, in order, as CSS
+// custom properties on the :root element, named after their entry and step,
+// e.g. "--color-1-500: #2c487c;".
+func EncodeScalesCSS(w io.Writer, scales []Scale, naming Naming) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(":root {\n")
+	for i, name := range naming.scaleNames(scales) {
+		for _, tone := range scales[i].Tones {
+			fmt.Fprintf(bw, "  --%s-%d: %s;\n", name, tone.Step, Entry{Color: tone.Color}.Hex())
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// EncodeScalesSCSS writes the tones of the given scales, in order, as SCSS
+// variables named after their entry and step, e.g. "$color-1-500: #2c487c;".
+func EncodeScalesSCSS(w io.Writer, scales []Scale, naming Naming) error {
+	bw := bufio.NewWriter(w)
+	for i, name := range naming.scaleNames(scales) {
+		for _, tone := range scales[i].Tones {
+			fmt.Fprintf(bw, "$%s-%d: %s;\n", name, tone.Step, Entry{Color: tone.Color}.Hex())
+		}
+	}
+	return bw.Flush()
+}
+
+// EncodeScalesTailwind writes the given scales, in order, as a Tailwind CSS
+// configuration module that sets theme.colors, with a shade for each step of
+// each scale, e.g. "bg-color-1-500".
+func EncodeScalesTailwind(w io.Writer, scales []Scale, naming Naming) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("module.exports = {\n  \"theme\": {\n    \"colors\": {\n")
+	for i, name := range naming.scaleNames(scales) {
+		fmt.Fprintf(bw, "      %s: {\n", jsonString(name))
+		for j, tone := range scales[i].Tones {
+			fmt.Fprintf(bw, "        \"%d\": %s", tone.Step, jsonString(Entry{Color: tone.Color}.Hex()))
+			if j < len(scales[i].Tones)-1 {
+				bw.WriteString(",")
+			}
+			bw.WriteString("\n")
+		}
+		bw.WriteString("      }")
+		if i < len(scales)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("    }\n  }\n};\n")
+	return bw.Flush()
+}
+
+// EncodeScalesDesignTokens writes the given scales, in order, as groups of
+// color tokens in the W3C Design Tokens Community Group format, with a token
+// for each step of each scale.
+func EncodeScalesDesignTokens(w io.Writer, scales []Scale, naming Naming) error {
+	type token struct {
+		Type  string `json:"$type"`
+		Value string `json:"$value"`
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{\n")
+	for i, name := range naming.scaleNames(scales) {
+		fmt.Fprintf(bw, "  %s: {\n", jsonString(name))
+		for j, tone := range scales[i].Tones {
+			data, err := json.Marshal(token{Type: "color", Value: Entry{Color: tone.Color}.Hex()})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(bw, "    \"%d\": %s", tone.Step, data)
+			if j < len(scales[i].Tones)-1 {
+				bw.WriteString(",")
+			}
+			bw.WriteString("\n")
+		}
+		bw.WriteString("  }")
+		if i < len(scales)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// jsonString quotes a string for JSON.
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
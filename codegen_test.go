@@ -0,0 +1,140 @@
+package palettor
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// codegenEntries are in an order other than by weight, to tell the namings
+// apart.
+var codegenEntries = []Entry{
+	{Color: forceHCL(color.RGBA{70, 130, 180, 255}), Weight: 0.25},
+	{Color: forceHCL(color.RGBA{72, 128, 182, 255}), Weight: 0.15},
+	{Color: forceHCL(color.RGBA{250, 250, 250, 255}), Weight: 0.6},
+}
+
+func TestNamings(t *testing.T) {
+	assert.Equal(t, []string{"color-1", "color-2", "color-3"}, NameByIndex.names(codegenEntries))
+	assert.Equal(t, []string{"rank-2", "rank-3", "rank-1"}, NameByRank.names(codegenEntries))
+	assert.Equal(t, []string{"steelblue", "steelblue-2", "white"}, NameByColor.names(codegenEntries))
+
+	// Entry names are preferred, and made into identifiers
+	named := []Entry{
+		{Color: forceHCL(color.RGBA{192, 115, 122, 255}), Weight: 0.5, Name: "Dusty Rose"},
+		{Color: forceHCL(color.RGBA{192, 115, 122, 255}), Weight: 0.5, Name: "dusty rose"},
+	}
+	assert.Equal(t, []string{"dusty-rose", "dusty-rose-2"}, NameByColor.names(named))
+}
+
+func TestEncodeCSS(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeCSS(&buf, codegenEntries, NameByIndex))
+	assert.Equal(t, `:root {
+  --color-1: #4682b4; /* 25.00% */
+  --color-2: #4880b6; /* 15.00% */
+  --color-3: #fafafa; /* 60.00% */
+}
+`, buf.String())
+}
+
+func TestEncodeSCSS(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeSCSS(&buf, codegenEntries, NameByColor))
+	assert.Equal(t, `$steelblue: #4682b4; // 25.00%
+$steelblue-2: #4880b6; // 15.00%
+$white: #fafafa; // 60.00%
+`, buf.String())
+}
+
+func TestEncodeTailwind(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeTailwind(&buf, codegenEntries, NameByRank))
+	module := buf.String()
+	assert.True(t, strings.HasPrefix(module, "module.exports = "))
+
+	// The configuration object is valid JSON
+	var config struct {
+		Theme struct {
+			Colors map[string]string
+		}
+	}
+	object := strings.TrimSuffix(strings.TrimPrefix(module, "module.exports = "), ";\n")
+	assert.NoError(t, json.Unmarshal([]byte(object), &config))
+	assert.Equal(t, map[string]string{
+		"rank-1": "#fafafa",
+		"rank-2": "#4682b4",
+		"rank-3": "#4880b6",
+	}, config.Theme.Colors)
+}
+
+func TestEncodeDesignTokens(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeDesignTokens(&buf, codegenEntries, NameByIndex))
+
+	var tokens map[string]struct {
+		Type        string `json:"$type"`
+		Value       string `json:"$value"`
+		Description string `json:"$description"`
+		Extensions  map[string]struct {
+			Weight float64
+		} `json:"$extensions"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &tokens))
+	assert.Len(t, tokens, 3)
+	token := tokens["color-3"]
+	assert.Equal(t, "color", token.Type)
+	assert.Equal(t, "#fafafa", token.Value)
+	assert.Equal(t, "60.00% of the image", token.Description)
+	assert.Equal(t, 0.6, token.Extensions["com.github.mccutchen.palettor"].Weight)
+
+	// Tokens are written in order
+	output := buf.String()
+	assert.True(t, strings.Index(output, `"color-1"`) < strings.Index(output, `"color-2"`))
+	assert.True(t, strings.Index(output, `"color-2"`) < strings.Index(output, `"color-3"`))
+}
+
+func TestEncodeScales(t *testing.T) {
+	scales := Scales(codegenEntries, 3, ScaleHCL)
+	hex := func(scale, tone int) string {
+		return Entry{Color: scales[scale].Tones[tone].Color}.Hex()
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeScalesCSS(&buf, scales, NameByIndex))
+	css := buf.String()
+	assert.True(t, strings.HasPrefix(css, ":root {\n  --color-1-250: "+hex(0, 0)+";\n  --color-1-500: "+hex(0, 1)+";\n"), css)
+	assert.Contains(t, css, "  --color-3-750: "+hex(2, 2)+";\n}\n")
+
+	buf.Reset()
+	assert.NoError(t, EncodeScalesSCSS(&buf, scales, NameByColor))
+	assert.True(t, strings.HasPrefix(buf.String(), "$steelblue-250: "+hex(0, 0)+";\n"))
+	assert.Contains(t, buf.String(), "$white-750: "+hex(2, 2)+";\n")
+
+	buf.Reset()
+	assert.NoError(t, EncodeScalesTailwind(&buf, scales, NameByRank))
+	var config struct {
+		Theme struct {
+			Colors map[string]map[string]string
+		}
+	}
+	object := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "module.exports = "), ";\n")
+	assert.NoError(t, json.Unmarshal([]byte(object), &config))
+	assert.Len(t, config.Theme.Colors, 3)
+	assert.Equal(t, map[string]string{"250": hex(2, 0), "500": hex(2, 1), "750": hex(2, 2)}, config.Theme.Colors["rank-1"])
+
+	buf.Reset()
+	assert.NoError(t, EncodeScalesDesignTokens(&buf, scales, NameByIndex))
+	var tokens map[string]map[string]struct {
+		Type  string `json:"$type"`
+		Value string `json:"$value"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &tokens))
+	assert.Len(t, tokens, 3)
+	assert.Equal(t, "color", tokens["color-2"]["500"].Type)
+	assert.Equal(t, hex(1, 1), tokens["color-2"]["500"].Value)
+}
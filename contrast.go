@@ -0,0 +1,231 @@
+package palettor
+
+import (
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Minimum WCAG 2.x contrast ratios between text and its background
+const (
+	// ContrastAA is the minimum contrast ratio of body text at level AA.
+	ContrastAA = 4.5
+	// ContrastAALarge is the minimum contrast ratio of large text, at least
+	// 18pt or 14pt bold, at level AA.
+	ContrastAALarge = 3.0
+	// ContrastAAA is the minimum contrast ratio of body text at level AAA.
+	ContrastAAA = 7.0
+)
+
+// relativeLuminance returns the relative luminance of a color as defined by
+// WCAG 2.x, from 0 for black to 1 for white.
+func relativeLuminance(c color.Color) float64 {
+	col, ok := colorful.MakeColor(c)
+	if !ok {
+		return 0
+	}
+	r, g, b := col.LinearRgb()
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// ContrastRatio returns the WCAG 2.x contrast ratio between two colors, from 1
+// for identical colors to 21 for black on white. The order of the colors does
+// not matter.
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// Constants of the APCA-W3 0.0.98G-4g contrast algorithm
+const (
+	apcaBlackThreshold = 0.022
+	apcaBlackClamp     = 1.414
+	apcaNormalBG       = 0.56
+	apcaNormalText     = 0.57
+	apcaReverseText    = 0.62
+	apcaReverseBG      = 0.65
+	apcaScale          = 1.14
+	apcaOffset         = 0.027
+	apcaClip           = 0.1
+	apcaMinDeltaY      = 0.0005
+)
+
+// apcaLuminance returns the screen luminance of a color as estimated by APCA,
+// with a soft clamp near black.
+func apcaLuminance(c color.Color) float64 {
+	col, ok := colorful.MakeColor(c)
+	if !ok {
+		return 0
+	}
+	y := 0.2126729*math.Pow(col.R, 2.4) + 0.7151522*math.Pow(col.G, 2.4) + 0.0721750*math.Pow(col.B, 2.4)
+	if y < apcaBlackThreshold {
+		y += math.Pow(apcaBlackThreshold-y, apcaBlackClamp)
+	}
+	return y
+}
+
+// APCAContrast returns the lightness contrast Lc of text on a background as
+// computed by APCA, the contrast algorithm proposed for WCAG 3. Unlike the
+// WCAG 2.x ratio, it depends on which color is the text: it is positive, up to
+// about 106, for dark text on a light background, and negative, down to about
+// -108, for light text on a dark background. An absolute Lc of 75 is
+// recommended for body text, and 60 for content text.
+func APCAContrast(text, background color.Color) float64 {
+	yText, yBG := apcaLuminance(text), apcaLuminance(background)
+	if math.Abs(yBG-yText) < apcaMinDeltaY {
+		return 0
+	}
+	var lc float64
+	if yBG > yText {
+		// Dark text on a light background
+		sapc := (math.Pow(yBG, apcaNormalBG) - math.Pow(yText, apcaNormalText)) * apcaScale
+		if sapc >= apcaClip {
+			lc = sapc - apcaOffset
+		}
+	} else {
+		// Light text on a dark background
+		sapc := (math.Pow(yBG, apcaReverseBG) - math.Pow(yText, apcaReverseText)) * apcaScale
+		if sapc <= -apcaClip {
+			lc = sapc + apcaOffset
+		}
+	}
+	return lc * 100
+}
+
+// A Pairing is a foreground color, e.g. of text, drawn over a background
+// color, along with the contrast between them.
+type Pairing struct {
+	Foreground Entry
+	Background Entry
+	// Ratio is the WCAG 2.x contrast ratio between the two colors.
+	Ratio float64
+	// APCA is the APCA lightness contrast of the foreground on the
+	// background.
+	APCA float64
+}
+
+func newPairing(foreground, background Entry) Pairing {
+	return Pairing{
+		Foreground: foreground,
+		Background: background,
+		Ratio:      ContrastRatio(foreground.Color, background.Color),
+		APCA:       APCAContrast(foreground.Color, background.Color),
+	}
+}
+
+// Pairings returns every ordered pair of distinct entries of a Palette whose
+// WCAG 2.x contrast ratio is at least minRatio, e.g. ContrastAA, from highest
+// to lowest contrast.
+func (p *Palette) Pairings(minRatio float64) []Pairing {
+	entries := p.EntriesBy(ByWeightDescending)
+	var pairings []Pairing
+	for _, background := range entries {
+		for _, foreground := range entries {
+			if foreground.Color == background.Color {
+				continue
+			}
+			if pairing := newPairing(foreground, background); pairing.Ratio >= minRatio {
+				pairings = append(pairings, pairing)
+			}
+		}
+	}
+	sortPairings(pairings)
+	return pairings
+}
+
+// BestForegrounds pairs each entry of a Palette, from most to least dominant,
+// with the other entry that contrasts with it the most, as the best choice of
+// foreground to draw on it. Palettes with a single entry have no pairings.
+func (p *Palette) BestForegrounds() []Pairing {
+	entries := p.EntriesBy(ByWeightDescending)
+	var pairings []Pairing
+	for _, background := range entries {
+		var best *Pairing
+		for _, foreground := range entries {
+			if foreground.Color == background.Color {
+				continue
+			}
+			pairing := newPairing(foreground, background)
+			if best == nil || pairing.Ratio > best.Ratio {
+				best = &pairing
+			}
+		}
+		if best != nil {
+			pairings = append(pairings, *best)
+		}
+	}
+	return pairings
+}
+
+// sortPairings sorts pairings from highest to lowest contrast, breaking ties by
+// the colors involved so that the order is deterministic.
+func sortPairings(pairings []Pairing) {
+	sort.SliceStable(pairings, func(i, j int) bool {
+		a, b := pairings[i], pairings[j]
+		if a.Ratio != b.Ratio {
+			return a.Ratio > b.Ratio
+		}
+		if ka, kb := asKey(a.Background.Color), asKey(b.Background.Color); ka != kb {
+			return ka.less(kb)
+		}
+		return asKey(a.Foreground.Color).less(asKey(b.Foreground.Color))
+	})
+}
+
+// AccessibleColor adjusts the HCL lightness of foreground, keeping its hue and
+// as much of its chroma as the sRGB gamut allows, until its WCAG 2.x contrast
+// ratio against background is at least minRatio. The lightness is changed as
+// little as possible, towards black or white, whichever contrasts more with
+// the background. Foreground colors that already meet the ratio are returned
+// as is.
+//
+// It reports false, along with the most contrasting color it found, if the
+// ratio cannot be met, e.g. for a minRatio above 21 or a mid grey background
+// and a high minRatio.
+func AccessibleColor(foreground, background color.Color, minRatio float64) (color.Color, bool) {
+	if ContrastRatio(foreground, background) >= minRatio {
+		return foreground, true
+	}
+	fg, err := toHCL(foreground)
+	if err != nil {
+		fg = hcl{}
+	}
+	target := 0.0
+	if ContrastRatio(color.White, background) > ContrastRatio(color.Black, background) {
+		target = 1
+	}
+	withLightness := func(l float64) hcl {
+		return clipChroma(hcl{fg.h, fg.c, l})
+	}
+	extreme := withLightness(target)
+	if ContrastRatio(extreme, background) < minRatio {
+		return extreme, false
+	}
+
+	// The contrast grows as the lightness moves towards the target, so find
+	// the lightness closest to the original that meets the ratio by bisection.
+	near, far := fg.l, target
+	for i := 0; i < 32; i++ {
+		mid := (near + far) / 2
+		if ContrastRatio(withLightness(mid), background) >= minRatio {
+			far = mid
+		} else {
+			near = mid
+		}
+	}
+	return withLightness(far), true
+}
+
+// TextColor returns a color for text drawn over an entry, in the hue of the
+// entry, whose WCAG 2.x contrast ratio against it is at least minRatio, e.g.
+// ContrastAA. See AccessibleColor. If the ratio cannot be met, it returns the
+// most contrasting tint or shade of the entry it found.
+func (e Entry) TextColor(minRatio float64) color.Color {
+	c, _ := AccessibleColor(e.Color, e.Color, minRatio)
+	return c
+}
@@ -0,0 +1,97 @@
+package palettor
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContrastRatio(t *testing.T) {
+	assert.InDelta(t, 21, ContrastRatio(color.Black, color.White), 0.001)
+	assert.InDelta(t, 21, ContrastRatio(color.White, color.Black), 0.001)
+	assert.Equal(t, 1.0, ContrastRatio(red, red))
+	// A well known value: #767676 is the lightest grey that passes AA on white
+	assert.InDelta(t, 4.54, ContrastRatio(color.RGBA{0x76, 0x76, 0x76, 0xff}, color.White), 0.01)
+	assert.Equal(t, 0.0, relativeLuminance(color.Black))
+	assert.InDelta(t, 0.2126, relativeLuminance(color.RGBA{0xff, 0, 0, 0xff}), 1e-6)
+}
+
+func TestAPCAContrast(t *testing.T) {
+	// Reference values of APCA-W3 0.0.98G-4g
+	assert.InDelta(t, 106.04, APCAContrast(color.Black, color.White), 0.01)
+	assert.InDelta(t, -107.88, APCAContrast(color.White, color.Black), 0.01)
+	grey := color.RGBA{0x88, 0x88, 0x88, 0xff}
+	assert.InDelta(t, 63.06, APCAContrast(grey, color.White), 0.01)
+	assert.InDelta(t, -68.54, APCAContrast(color.White, grey), 0.01)
+	assert.Equal(t, 0.0, APCAContrast(red, red))
+}
+
+func TestPairings(t *testing.T) {
+	palette := &Palette{}
+	palette.add(black, 0.5)
+	palette.add(white, 0.3)
+	palette.add(forceHCL(color.RGBA{0x76, 0x76, 0x76, 0xff}), 0.2)
+
+	assert.Len(t, palette.Pairings(ContrastAA), 6)
+	pairings := palette.Pairings(ContrastAAA)
+	assert.Len(t, pairings, 2, "only black and white meet AAA together")
+	assert.Equal(t, black, pairings[0].Background.Color)
+	assert.Equal(t, white, pairings[0].Foreground.Color)
+	assert.InDelta(t, 21, pairings[0].Ratio, 0.001)
+	assert.InDelta(t, -107.88, pairings[0].APCA, 0.01)
+	assert.Equal(t, white, pairings[1].Background.Color)
+	assert.InDelta(t, 106.04, pairings[1].APCA, 0.01)
+	pairings = palette.Pairings(0)
+	for i := 1; i < len(pairings); i++ {
+		assert.True(t, pairings[i-1].Ratio >= pairings[i].Ratio)
+	}
+
+	best := palette.BestForegrounds()
+	assert.Len(t, best, 3)
+	assert.Equal(t, black, best[0].Background.Color)
+	assert.Equal(t, white, best[0].Foreground.Color)
+	assert.Equal(t, black, best[2].Foreground.Color, "black contrasts more than white with #767676")
+	assert.InDelta(t, 4.62, best[2].Ratio, 0.01)
+
+	single := &Palette{}
+	single.add(black, 1)
+	assert.Empty(t, single.BestForegrounds())
+}
+
+func TestAccessibleColor(t *testing.T) {
+	steelblue := forceHCL(color.RGBA{70, 130, 180, 255})
+	for _, tc := range []struct {
+		background color.Color
+		minRatio   float64
+	}{
+		{color.White, ContrastAA},
+		{color.White, ContrastAAA},
+		{color.Black, ContrastAAA},
+		{steelblue, ContrastAALarge},
+		{steelblue, ContrastAA},
+	} {
+		c, ok := AccessibleColor(steelblue, tc.background, tc.minRatio)
+		assert.True(t, ok)
+		ratio := ContrastRatio(c, tc.background)
+		assert.True(t, ratio >= tc.minRatio, "%v on %v: %v < %v", c, tc.background, ratio, tc.minRatio)
+		// The ratio is met with as small a change as possible, in the same hue
+		assert.InDelta(t, tc.minRatio, ratio, 0.01)
+		assert.InDelta(t, steelblue.h, c.(hcl).h, 2)
+	}
+
+	// Colors that already meet the ratio are untouched
+	c, ok := AccessibleColor(steelblue, color.White, ContrastAALarge)
+	assert.True(t, ok)
+	assert.Equal(t, steelblue, c)
+
+	// Nothing contrasts with mid grey at 7:1
+	grey := color.RGBA{0x77, 0x77, 0x77, 0xff}
+	_, ok = AccessibleColor(grey, grey, ContrastAAA)
+	assert.False(t, ok)
+
+	entry := Entry{Color: steelblue, Weight: 1}
+	text := entry.TextColor(ContrastAA)
+	assert.True(t, ContrastRatio(text, steelblue) >= ContrastAA)
+	assert.True(t, entryHCL(Entry{Color: text}).l < steelblue.l, "dark text contrasts most with steelblue")
+}
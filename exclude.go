@@ -0,0 +1,145 @@
+package palettor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// An exclusion drops every pixel within tolerance ΔE of color.
+type exclusion struct {
+	color     color.Color
+	tolerance float64
+}
+
+type resolvedExclusion struct {
+	color     hcl
+	tolerance float64
+}
+
+// exclusions resolves the configured exclusions for the given image,
+// detecting its background color if necessary.
+func (cfg *config) exclusions(img image.Image) ([]resolvedExclusion, error) {
+	var result []resolvedExclusion
+	for _, ex := range cfg.excludedColors {
+		c, err := toHCL(ex.color)
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded color: %w", err)
+		}
+		result = append(result, resolvedExclusion{c, ex.tolerance})
+	}
+	if cfg.excludeBackground {
+		background, err := detectBackground(img, cfg.converter())
+		if err != nil {
+			return nil, fmt.Errorf("error detecting background color: %w", err)
+		}
+		result = append(result, resolvedExclusion{background, cfg.backgroundTolerance})
+	}
+	return result, nil
+}
+
+// exclude returns the colors that are not within tolerance of any exclusion,
+// along with their weights. The given slices are filtered in place.
+func exclude(colors []hcl, weights []float64, exclusions []resolvedExclusion) ([]hcl, []float64) {
+	if len(exclusions) == 0 {
+		return colors, weights
+	}
+	kept := colors[:0]
+	var keptWeights []float64
+	if weights != nil {
+		keptWeights = weights[:0]
+	}
+	for i, c := range colors {
+		if !isExcluded(c, exclusions) {
+			kept = append(kept, c)
+			if weights != nil {
+				keptWeights = append(keptWeights, weights[i])
+			}
+		}
+	}
+	return kept, keptWeights
+}
+
+func isExcluded(c hcl, exclusions []resolvedExclusion) bool {
+	for _, ex := range exclusions {
+		if c.deltaE(ex.color) <= ex.tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectBackground estimates the background color of an image from the pixels
+// along its border: they are grouped into coarse color buckets, and the mean
+// color of the most populated bucket is returned.
+func DetectBackground(img image.Image) (color.Color, error) {
+	c, err := detectBackground(img, rgbaToHCL)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// detectBackground is DetectBackground, using convert to convert the mean
+// border color to HCL.
+func detectBackground(img image.Image, convert converter) (hcl, error) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return hcl{}, errors.New("cannot detect background of an empty image")
+	}
+
+	type bucket struct {
+		count   int
+		r, g, b uint64
+	}
+	buckets := make(map[[3]uint32]*bucket)
+	var best *bucket
+
+	visit := func(x, y int) {
+		c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+		if c.A == 0 {
+			return
+		}
+		// 16 levels per channel is coarse enough to absorb compression
+		// noise and gentle gradients in a studio background.
+		key := [3]uint32{uint32(c.R >> 12), uint32(c.G >> 12), uint32(c.B >> 12)}
+		bkt, ok := buckets[key]
+		if !ok {
+			bkt = &bucket{}
+			buckets[key] = bkt
+		}
+		bkt.count++
+		bkt.r += uint64(c.R)
+		bkt.g += uint64(c.G)
+		bkt.b += uint64(c.B)
+		if best == nil || bkt.count > best.count {
+			best = bkt
+		}
+	}
+
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		visit(x, bounds.Min.Y)
+		if bounds.Dy() > 1 {
+			visit(x, bounds.Max.Y-1)
+		}
+	}
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		visit(bounds.Min.X, y)
+		if bounds.Dx() > 1 {
+			visit(bounds.Max.X-1, y)
+		}
+	}
+
+	if best == nil {
+		return hcl{}, errors.New("image border is fully transparent")
+	}
+	n := uint64(best.count)
+	c, _ := convert(color.NRGBA64{
+		R: uint16(best.r / n),
+		G: uint16(best.g / n),
+		B: uint16(best.b / n),
+		A: 0xffff,
+	}.RGBA())
+	return c, nil
+}
@@ -0,0 +1,70 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// productImage returns a 10x10 image with a studio-grey background and a 4x4
+// red "product" in the middle.
+func productImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	grey := color.RGBA{230, 230, 232, 255}
+	draw.Draw(img, img.Bounds(), &image.Uniform{grey}, image.Point{}, draw.Src)
+	// A little noise in the background should not matter
+	img.Set(0, 0, color.RGBA{228, 231, 230, 255})
+	draw.Draw(img, image.Rect(3, 3, 7, 7), &image.Uniform{color.RGBA{200, 0, 0, 255}}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestDetectBackground(t *testing.T) {
+	background, err := DetectBackground(productImage())
+	assert.NoError(t, err)
+	r, g, b, _ := background.RGBA()
+	assert.InDelta(t, 230, r>>8, 1)
+	assert.InDelta(t, 230, g>>8, 1)
+	assert.InDelta(t, 232, b>>8, 1)
+
+	_, err = DetectBackground(image.NewRGBA(image.Rect(0, 0, 0, 0)))
+	assert.Error(t, err, "empty image should result in an error")
+
+	_, err = DetectBackground(image.NewRGBA(image.Rect(0, 0, 3, 3)))
+	assert.Error(t, err, "transparent border should result in an error")
+}
+
+func TestExtractWithExclusion(t *testing.T) {
+	img := productImage()
+
+	palette, err := Extract(1, 100, img, WithExclusion(color.RGBA{230, 230, 232, 255}, 3))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.84, palette.Excluded(), 0.0001)
+	assertOnlyRed(t, palette)
+
+	palette, err = Extract(1, 100, img, WithBackgroundExclusion(3))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.84, palette.Excluded(), 0.0001)
+	assertOnlyRed(t, palette)
+
+	palette, err = Extract(1, 100, img)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, palette.Excluded())
+
+	_, err = Extract(1, 100, img, WithExclusion(color.RGBA{}, 3))
+	assert.Error(t, err, "transparent exclusion color should result in an error")
+
+	_, err = Extract(1, 100, img, WithExclusion(color.White, 1000))
+	assert.Error(t, err, "excluding every pixel should result in an error")
+}
+
+func assertOnlyRed(t *testing.T, palette *Palette) {
+	t.Helper()
+	entries := palette.Entries()
+	if assert.Len(t, entries, 1) {
+		r, g, b, _ := entries[0].Color.RGBA()
+		assert.Equal(t, []uint32{200, 0, 0}, []uint32{r >> 8, g >> 8, b >> 8})
+	}
+}
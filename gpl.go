@@ -0,0 +1,100 @@
+package palettor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EncodeGPL writes the given entries, in order, as a GIMP palette (.gpl) with
+// the given name. The format is also read by Inkscape, Krita and others.
+//
+// Each color is named by the name of its entry, or by its hex value if it has
+// none, and its weight is recorded in a comment line just above it, which
+// DecodeGPL reads back.
+func EncodeGPL(w io.Writer, name string, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "GIMP Palette\nName: %s\nColumns: %d\n#\n", gplEscape(name), len(entries))
+	for _, e := range entries {
+		r, g, b := e.colorful().RGB255()
+		name := gplEscape(e.Name)
+		if name == "" {
+			name = e.Hex()
+		}
+		fmt.Fprintf(bw, "# weight %g\n", e.Weight)
+		fmt.Fprintf(bw, "%3d %3d %3d\t%s\n", r, g, b, name)
+	}
+	return bw.Flush()
+}
+
+// DecodeGPL reads a GIMP palette, keeping the names of its colors.
+func DecodeGPL(r io.Reader) (*Palette, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "GIMP Palette" {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("not a GIMP palette: missing header")
+	}
+
+	var entries []Entry
+	var weight float64
+	for line := 2; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "#"):
+			fields := strings.Fields(strings.TrimPrefix(text, "#"))
+			if len(fields) == 2 && fields[0] == "weight" {
+				w, err := strconv.ParseFloat(fields[1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid weight: %w", line, err)
+				}
+				weight = w
+			}
+			continue
+		case strings.HasPrefix(text, "Name:"), strings.HasPrefix(text, "Columns:"):
+			continue
+		}
+
+		// A color line has three channel values, optionally followed by a
+		// name, which may contain spaces.
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected R G B values, got %q", line, text)
+		}
+		var rgb [3]uint8
+		for i := range rgb {
+			v, err := strconv.ParseUint(fields[i], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid channel value %q", line, fields[i])
+			}
+			rgb[i] = uint8(v)
+		}
+		entry := Entry{Color: color.RGBA{rgb[0], rgb[1], rgb[2], 0xff}, Weight: weight}
+		// Colors named by their hex value, as by EncodeGPL, are left to be
+		// named after a Dictionary.
+		if name := strings.Join(fields[3:], " "); !strings.EqualFold(name, entry.Hex()) {
+			entry.Name = name
+		}
+		entries = append(entries, entry)
+		weight = 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("GIMP palette has no colors")
+	}
+	return paletteOf(entries), nil
+}
+
+// gplEscape keeps a palette name on a single line.
+func gplEscape(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,114 @@
+package palettor
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGPLRoundTrip(t *testing.T) {
+	palette := &Palette{}
+	palette.add(forceHCL(color.RGBA{200, 0, 0, 255}), 0.16)
+	palette.add(forceHCL(color.RGBA{230, 230, 232, 255}), 0.84)
+
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeGPL(&buf, "product\nshot", palette.Entries()))
+	assert.True(t, strings.HasPrefix(buf.String(), "GIMP Palette\nName: product shot\nColumns: 2\n"))
+
+	decoded, err := DecodeGPL(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, palette.Count(), decoded.Count())
+	for i, entry := range decoded.Entries() {
+		original := palette.Entries()[i]
+		assert.Equal(t, color.RGBAModel.Convert(original.Color), entry.Color)
+		assert.InDelta(t, original.Weight, entry.Weight, 1e-9)
+	}
+}
+
+func TestGPLRoundTripNames(t *testing.T) {
+	f, err := os.Open("testdata/tango.gpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	palette, err := DecodeGPL(f)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	entries := palette.EntriesBy(ByHue)
+	assert.NoError(t, EncodeGPL(&buf, "Tango", append(entries, Entry{Color: color.RGBA{1, 2, 3, 255}, Weight: 0})))
+	assert.Contains(t, buf.String(), "252 233  79\tButter 1\n")
+	assert.Contains(t, buf.String(), "  1   2   3\t#010203\n", "unnamed entries should be named by their hex value")
+
+	decoded, err := DecodeGPL(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, len(entries)+1, decoded.Count())
+	names := make(map[color.Color]string)
+	for _, e := range decoded.Entries() {
+		names[e.Color] = e.Name
+	}
+	for _, entry := range entries {
+		assert.Equal(t, entry.Name, names[entry.Color])
+	}
+}
+
+func TestDecodeGPLWithoutWeights(t *testing.T) {
+	f, err := os.Open("testdata/tango.gpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	palette, err := DecodeGPL(f)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, palette.Count())
+	butter := color.RGBA{252, 233, 79, 255}
+	assert.InDelta(t, 1.0/6, palette.Weight(butter), 1e-9)
+
+	// Names are kept
+	for _, entry := range palette.Entries() {
+		if entry.Color == butter {
+			assert.Equal(t, "Butter 1", entry.Name)
+		}
+	}
+}
+
+func TestDecodeGPLMixedWeights(t *testing.T) {
+	input := `GIMP Palette
+# weight 0.75
+255   0   0	#ff0000
+# weight 0.25
+  0   0 255	#0000ff
+255 255 255	Paper
+  0   0   0	Ink
+`
+	palette, err := DecodeGPL(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.375, palette.Weight(color.RGBA{255, 0, 0, 255}), 1e-9)
+	assert.InDelta(t, 0.125, palette.Weight(color.RGBA{0, 0, 255, 255}), 1e-9)
+	assert.InDelta(t, 0.25, palette.Weight(color.RGBA{255, 255, 255, 255}), 1e-9)
+	assert.InDelta(t, 0.25, palette.Weight(color.RGBA{0, 0, 0, 255}), 1e-9)
+
+	// Hex values written by EncodeGPL are not names
+	entries := palette.EntriesBy(ByWeightDescending)
+	assert.Equal(t, "red", entries[0].Name)
+	assert.Equal(t, "blue", entries[3].Name)
+	assert.ElementsMatch(t, []string{"Paper", "Ink"}, []string{entries[1].Name, entries[2].Name})
+}
+
+func TestDecodeGPLInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"missing header": "Name: nope\n0 0 0\n",
+		"no colors":      "GIMP Palette\nName: empty\n",
+		"bad channel":    "GIMP Palette\n0 256 0\tToo green\n",
+		"short line":     "GIMP Palette\n0 0\n",
+		"bad weight":     "GIMP Palette\n# weight heavy\n0 0 0\n",
+	} {
+		_, err := DecodeGPL(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
@@ -9,32 +9,94 @@ import (
 )
 
 func toHCL(col color.Color) (hcl, error) {
-	intermediate, ok := colorful.MakeColor(col)
+	if c, ok := col.(hcl); ok {
+		return c, nil
+	}
+	c, ok := rgbaToHCL(col.RGBA())
 	if !ok {
 		return hcl{}, fmt.Errorf("color has alpha channel 0: %+v", col)
 	}
-	h, c, l := intermediate.Hcl()
-	return hcl{h, c, l}, nil
+	return c, nil
+}
+
+// rgbaToHCL converts alpha-premultiplied 16-bit channels, as returned by
+// color.Color's RGBA method, to HCL. It reports false if alpha is 0.
+func rgbaToHCL(r, g, b, a uint32) (hcl, bool) {
+	if a == 0 {
+		return hcl{}, false
+	}
+	// Undo the alpha premultiplication exactly as colorful.MakeColor does.
+	return fromColorful(colorful.Color{
+		R: float64(r*0xffff/a) / 0xffff,
+		G: float64(g*0xffff/a) / 0xffff,
+		B: float64(b*0xffff/a) / 0xffff,
+	}), true
+}
+
+// fromColorful converts a colorful.Color to HCL.
+//
+// Unlike colorful's own Hcl method, which snaps the hue of nearly achromatic
+// colors to 0, the hue is always kept so that the conversion can be exactly
+// reversed, preserving the full precision of the original color. Hue is
+// meaningless for such colors anyway, and is scaled by chroma wherever it is
+// used.
+func fromColorful(col colorful.Color) hcl {
+	l, a, b := col.Lab()
+	return hcl{
+		h: degrees(math.Atan2(b, a)),
+		c: math.Hypot(a, b),
+		l: l,
+	}
 }
 
 type hcl struct {
 	h, c, l float64
 }
 
-// RGBA implements color.Color.
+// RGBA implements color.Color, at the full 16-bit precision it allows.
 func (c hcl) RGBA() (r, g, b, a uint32) {
-	// Bodge: squash floating point error to simplify testing with expected
-	// output palettes.
-	rFloat, gFloat, bFloat := colorful.Hcl(c.h, c.c, c.l).Clamped().RGB255()
-	return color.RGBA{rFloat, gFloat, bFloat, 255}.RGBA()
+	return c.colorful().Clamped().RGBA()
+}
+
+// deltaE calculates the CIEDE2000 color difference between two colors, on the
+// conventional scale where a difference of about 2.3 is just noticeable.
+func (c hcl) deltaE(other hcl) float64 {
+	return 100 * c.colorful().DistanceCIEDE2000(other.colorful())
+}
+
+func (c hcl) colorful() colorful.Color {
+	return colorful.Hcl(c.h, c.c, c.l)
+}
+
+// clipChroma reduces the chroma of c, keeping its hue and lightness, until it
+// falls within the sRGB gamut.
+func clipChroma(c hcl) hcl {
+	if c.colorful().IsValid() {
+		return c
+	}
+	low, high := 0.0, c.c
+	for i := 0; i < 24; i++ {
+		mid := (low + high) / 2
+		if (hcl{c.h, mid, c.l}).colorful().IsValid() {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	// Lightness itself may be out of range, which clamping takes care of.
+	return fromColorful(hcl{c.h, low, c.l}.colorful().Clamped())
 }
 
-// Calculate the square of the Euclidean distance between two colors, ignoring
-// the alpha channel.
+// Calculate the square of the distance between two colors, ignoring the alpha
+// channel.
 //
-// Note: we may want to weight these to get greater C/L variance.
+// As in LCh-based ΔE formulas, the hue difference is expressed as the chord
+// between the two hues at the colors' (geometric mean) chroma, so that it is
+// on the same scale as the lightness and chroma differences, and vanishes for
+// achromatic colors, whose hue is just noise. The result is the square of the
+// Euclidean distance between the colors in L*a*b* space.
 func (c hcl) distanceSquared(other hcl) float64 {
-	dh := c.hueDistance(other)
+	dh := 2 * math.Sqrt(c.c*other.c) * math.Sin(radians(c.hueDistance(other))/2)
 	dc := c.c - other.c
 	dl := c.l - other.l
 	return dh*dh + dc*dc + dl*dl
@@ -44,47 +106,104 @@ func (c hcl) distanceSquared(other hcl) float64 {
 // arithmetic distance can be misleading: 0 and 360 have an arithmetic delta of
 // 360, but they coincide (zero hue distance).
 func (c hcl) hueDistance(other hcl) float64 {
-	delta := math.Mod(other.h-c.h, 360)
+	delta := math.Abs(math.Mod(other.h-c.h, 360))
 	// Pick the shorter angular distance: 'clockwise' or 'counterclockwise'
 	// around the unit circle.
-	return math.Min(
-		math.Abs(delta),
-		math.Abs(360-delta),
-	)
+	return math.Min(delta, 360-delta)
 }
 
 func mean(colors []hcl) hcl {
+	return weightedMean(colors, nil)
+}
+
+// weightedMean finds the mean of the given colors, each contributing in
+// proportion to its weight. A nil weights slice weights all colors equally.
+func weightedMean(colors []hcl, weights []float64) hcl {
 	return hcl{
-		h: meanHue(colors),
-		c: arithmeticMean(colors, func(c hcl) float64 { return c.c }),
-		l: arithmeticMean(colors, func(c hcl) float64 { return c.l }),
+		h: weightedMeanHue(colors, weights),
+		c: arithmeticMean(colors, weights, func(c hcl) float64 { return c.c }),
+		l: arithmeticMean(colors, weights, func(c hcl) float64 { return c.l }),
 	}
 }
 
 // meanHue implements a circular mean: averaging H-values can lead to visually
 // improper centroids. See https://en.wikipedia.org/wiki/Circular_mean#Example
 func meanHue(colors []hcl) float64 {
-	meanSin := arithmeticMean(colors, func(c hcl) float64 {
-		return math.Sin(radians(c.h))
-	})
-	meanCos := arithmeticMean(colors, func(c hcl) float64 {
-		return math.Cos(radians(c.h))
-	})
-	return degrees(math.Atan(meanSin / meanCos))
+	return weightedMeanHue(colors, nil)
+}
+
+// weightedMeanHue finds the circular mean of the hues of the given colors,
+// i.e. the angle minimizing the weighted sum of 1 - cos(h - mean).
+//
+// The hue of an achromatic color is meaningless noise, so each hue is also
+// weighted by its color's chroma, which makes the result the hue of the mean
+// color in L*a*b* space. If every color is achromatic, the hues are weighted
+// by the given weights alone. If the hues cancel out entirely, the mean hue
+// is undefined and 0 is returned, following the convention for greys.
+func weightedMeanHue(colors []hcl, weights []float64) float64 {
+	sin, cos, length := hueResultant(colors, weights, func(c hcl) float64 { return c.c })
+	if length == 0 {
+		sin, cos, length = hueResultant(colors, weights, func(hcl) float64 { return 1 })
+	}
+	if length < undefinedHueThreshold {
+		return 0
+	}
+	return degrees(math.Atan2(sin, cos))
+}
+
+// undefinedHueThreshold is the mean resultant length below which the hues
+// being averaged are considered to cancel out.
+const undefinedHueThreshold = 1e-9
+
+// hueResultant sums the unit vectors of the hues of the given colors, each
+// scaled by its weight and by scale. It returns the components of the
+// resulting vector along with its length relative to the total weight.
+func hueResultant(colors []hcl, weights []float64, scale func(hcl) float64) (sin, cos, length float64) {
+	var total float64
+	for i, c := range colors {
+		w := weightAt(weights, i) * scale(c)
+		sin += w * math.Sin(radians(c.h))
+		cos += w * math.Cos(radians(c.h))
+		total += w
+	}
+	if total == 0 {
+		return 0, 0, 0
+	}
+	return sin, cos, math.Hypot(sin, cos) / total
 }
 
 func radians(degrees float64) float64 {
 	return degrees * (math.Pi / 180)
 }
 
+// degrees converts an angle in radians to degrees in the range [0, 360).
 func degrees(radians float64) float64 {
-	return math.Mod(radians*(180/math.Pi), 360)
+	d := math.Mod(radians*(180/math.Pi), 360)
+	if d < 0 {
+		d += 360
+	}
+	// Adding 360 to a tiny negative angle can round up to 360 itself.
+	if d >= 360 {
+		d = 0
+	}
+	return d
+}
+
+func arithmeticMean(colors []hcl, weights []float64, accessor func(hcl) float64) float64 {
+	var sum, total float64
+	for i, c := range colors {
+		w := weightAt(weights, i)
+		sum += w * accessor(c)
+		total += w
+	}
+	return sum / total
 }
 
-func arithmeticMean(colors []hcl, accessor func(hcl) float64) float64 {
-	var sum float64
-	for _, c := range colors {
-		sum += accessor(c)
+// weightAt returns the weight of the i-th color, treating a nil weights slice
+// as weighting every color equally.
+func weightAt(weights []float64, i int) float64 {
+	if weights == nil {
+		return 1
 	}
-	return sum / float64(len(colors))
+	return weights[i]
 }
@@ -2,7 +2,11 @@ package palettor
 
 import (
 	"image/color"
+	"math"
+	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -30,6 +34,33 @@ func TestHueDistance(t *testing.T) {
 
 	assert.InDelta(t, 10, hcl{h: 5}.hueDistance(hcl{h: 355}), 0.001)
 	assert.InDelta(t, 10, hcl{h: 5}.hueDistance(hcl{h: -5}), 0.001)
+	assert.InDelta(t, 10, hcl{h: 355}.hueDistance(hcl{h: 5}), 0.001, "distance should be symmetric")
+	assert.InDelta(t, 180, hcl{h: 270}.hueDistance(hcl{h: 90}), 0.001)
+}
+
+func TestDistanceSquaredScalesHueByChroma(t *testing.T) {
+	// For achromatic colors, hue is noise and should not matter at all
+	assert.InDelta(t, 0, hcl{h: 0, c: 0, l: 0.5}.distanceSquared(hcl{h: 180, c: 0, l: 0.5}), 1e-12)
+
+	// Opposite hues at equal chroma are a diameter apart, on the same scale
+	// as chroma and lightness
+	assert.InDelta(t, 0.04, hcl{h: 0, c: 0.1, l: 0.5}.distanceSquared(hcl{h: 180, c: 0.1, l: 0.5}), 1e-9)
+
+	// The distance matches Euclidean distance in L*a*b* space
+	for i := 0; i < 100; i++ {
+		a, b := forceHCL(randomColor()), forceHCL(randomColor())
+		l1, a1, b1 := a.colorful().Lab()
+		l2, a2, b2 := b.colorful().Lab()
+		expected := (l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2)
+		assert.InDelta(t, expected, a.distanceSquared(b), 1e-9)
+	}
+
+	// Near-black pixels with random hues are closer to each other than to
+	// dark grey
+	nearBlackRed := forceHCL(color.RGBA{3, 1, 1, 255})
+	nearBlackBlue := forceHCL(color.RGBA{1, 1, 3, 255})
+	grey := forceHCL(color.RGBA{40, 40, 40, 255})
+	assert.Less(t, nearBlackRed.distanceSquared(nearBlackBlue), nearBlackRed.distanceSquared(grey))
 }
 
 func TestColor(t *testing.T) {
@@ -57,3 +88,146 @@ func TestMeanHue(t *testing.T) {
 	})
 	assert.InDelta(t, 5, result, 0.001)
 }
+
+func TestMeanHueAllQuadrants(t *testing.T) {
+	for _, tc := range []struct {
+		hues     []float64
+		expected float64
+	}{
+		{[]float64{10, 30}, 20},
+		{[]float64{100, 120}, 110},
+		{[]float64{170, 190}, 180},
+		{[]float64{200, 220}, 210},
+		{[]float64{280, 300}, 290},
+		{[]float64{350, 30}, 10},
+		{[]float64{80, 100}, 90},
+		{[]float64{260, 280}, 270},
+	} {
+		var colors []hcl
+		for _, h := range tc.hues {
+			colors = append(colors, hcl{h: h, c: 0.5, l: 0.5})
+		}
+		assert.InDelta(t, tc.expected, meanHue(colors), 0.001, "mean of hues %v", tc.hues)
+	}
+}
+
+func TestMeanHueUndefined(t *testing.T) {
+	// Opposite hues cancel out, which would divide by zero in a naive
+	// atan(sin/cos) implementation
+	assert.Equal(t, 0.0, meanHue([]hcl{{h: 90, c: 0.5}, {h: 270, c: 0.5}}))
+	assert.Equal(t, 0.0, meanHue([]hcl{{h: 0, c: 0.5}, {h: 120, c: 0.5}, {h: 240, c: 0.5}}))
+
+	// Achromatic colors don't pull the hue of chromatic ones
+	assert.InDelta(t, 200, meanHue([]hcl{{h: 200, c: 0.5}, {h: 20, c: 0}, {h: 20, c: 0}}), 0.001)
+
+	// Weights are respected
+	assert.InDelta(t, 10, weightedMeanHue([]hcl{{h: 10, c: 0.5}, {h: 100, c: 0.5}}, []float64{1, 0}), 0.001)
+}
+
+// hueSample is a random set of weighted colors for property-based tests of
+// the circular mean.
+type hueSample struct {
+	colors  []hcl
+	weights []float64
+}
+
+func (hueSample) Generate(rand *rand.Rand, size int) reflect.Value {
+	n := 1 + rand.Intn(size+1)
+	s := hueSample{
+		colors:  make([]hcl, n),
+		weights: make([]float64, n),
+	}
+	// Cluster hues around a random center, as k-means clusters are, with
+	// a random spread that sometimes covers the whole circle.
+	center := rand.Float64() * 360
+	spread := rand.Float64() * 360
+	for i := range s.colors {
+		s.colors[i] = hcl{
+			h: math.Mod(center+(rand.Float64()-0.5)*spread+360, 360),
+			c: rand.Float64(),
+			l: rand.Float64(),
+		}
+		s.weights[i] = 0.1 + rand.Float64()
+	}
+	return reflect.ValueOf(s)
+}
+
+// circularLoss is the quantity minimized by the circular mean.
+func (s hueSample) circularLoss(mean float64) float64 {
+	var loss float64
+	for i, c := range s.colors {
+		loss += s.weights[i] * c.c * (1 - math.Cos(radians(c.h-mean)))
+	}
+	return loss
+}
+
+// bruteForceMean finds the hue minimizing circularLoss by exhaustive search
+// over a fine grid, refined by ternary search around the best grid point.
+func (s hueSample) bruteForceMean() float64 {
+	const step = 0.5
+	best := 0.0
+	for h := 0.0; h < 360; h += step {
+		if s.circularLoss(h) < s.circularLoss(best) {
+			best = h
+		}
+	}
+	lo, hi := best-step, best+step
+	for i := 0; i < 60; i++ {
+		a, b := lo+(hi-lo)/3, hi-(hi-lo)/3
+		if s.circularLoss(a) < s.circularLoss(b) {
+			hi = b
+		} else {
+			lo = a
+		}
+	}
+	return math.Mod((lo+hi)/2+360, 360)
+}
+
+func TestMeanHueProperties(t *testing.T) {
+	config := &quick.Config{MaxCount: 300}
+
+	minimizesLoss := func(s hueSample) bool {
+		mean := weightedMeanHue(s.colors, s.weights)
+		if mean < 0 || mean >= 360 {
+			return false
+		}
+		// The mean must be at least as good as the brute-force minimum.
+		// When the hues nearly cancel out the loss is flat, and any hue is
+		// (almost) as good as any other, so the loss is compared rather
+		// than the angle.
+		return s.circularLoss(mean) <= s.circularLoss(s.bruteForceMean())+1e-9
+	}
+	if err := quick.Check(minimizesLoss, config); err != nil {
+		t.Error("mean hue should minimize the circular loss:", err)
+	}
+
+	matchesBruteForce := func(s hueSample) bool {
+		_, _, length := hueResultant(s.colors, s.weights, func(c hcl) float64 { return c.c })
+		if length < 0.1 {
+			// The minimum is too shallow to pin down the angle
+			return true
+		}
+		mean := weightedMeanHue(s.colors, s.weights)
+		return hcl{h: mean}.hueDistance(hcl{h: s.bruteForceMean()}) < 0.01
+	}
+	if err := quick.Check(matchesBruteForce, config); err != nil {
+		t.Error("mean hue should match brute-force minimization:", err)
+	}
+
+	rotationEquivariant := func(s hueSample, rotation uint16) bool {
+		delta := float64(rotation % 360)
+		rotated := hueSample{colors: make([]hcl, len(s.colors)), weights: s.weights}
+		for i, c := range s.colors {
+			rotated.colors[i] = hcl{h: math.Mod(c.h+delta, 360), c: c.c, l: c.l}
+		}
+		_, _, length := hueResultant(s.colors, s.weights, func(c hcl) float64 { return c.c })
+		if length < 0.1 {
+			return true
+		}
+		expected := math.Mod(weightedMeanHue(s.colors, s.weights)+delta, 360)
+		return hcl{h: weightedMeanHue(rotated.colors, rotated.weights)}.hueDistance(hcl{h: expected}) < 1e-6
+	}
+	if err := quick.Check(rotationEquivariant, config); err != nil {
+		t.Error("rotating every hue should rotate the mean:", err)
+	}
+}
@@ -0,0 +1,670 @@
+package palettor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// A Profile describes the RGB color space in which the pixels of an image are
+// encoded, as recorded by the ICC color profile embedded in the image file.
+//
+// Images are assumed to be sRGB unless told otherwise. Photos from many
+// cameras and phones are instead encoded in wider gamut spaces like Display
+// P3, and their saturated colors come out dull or shifted if read as sRGB.
+// Passing the image's Profile to Extract with WithSourceProfile converts each
+// pixel into the working space before clustering.
+type Profile struct {
+	name    string
+	toXYZ   matrix3
+	fromXYZ matrix3
+	curves  [3]toneCurve
+}
+
+var (
+	// ProfileSRGB is the standard sRGB color space assumed for untagged
+	// images.
+	ProfileSRGB = newProfile("sRGB", [3][2]float64{{0.64, 0.33}, {0.30, 0.60}, {0.15, 0.06}}, srgbCurve)
+	// ProfileDisplayP3 is the Display P3 color space used by Apple devices.
+	ProfileDisplayP3 = newProfile("Display P3", [3][2]float64{{0.680, 0.320}, {0.265, 0.690}, {0.150, 0.060}}, srgbCurve)
+	// ProfileAdobeRGB is the Adobe RGB (1998) color space.
+	ProfileAdobeRGB = newProfile("Adobe RGB (1998)", [3][2]float64{{0.64, 0.33}, {0.21, 0.71}, {0.15, 0.06}}, parametricCurve{g: 563.0 / 256, a: 1})
+
+	knownProfiles = []*Profile{ProfileSRGB, ProfileDisplayP3, ProfileAdobeRGB}
+)
+
+// knownProfile returns the predefined Profile with the given name, or nil if
+// there is none.
+func knownProfile(name string) *Profile {
+	for _, known := range knownProfiles {
+		if known.Name() == name {
+			return known
+		}
+	}
+	return nil
+}
+
+// srgbCurve is the transfer function shared by sRGB and Display P3.
+var srgbCurve = parametricCurve{g: 2.4, a: 1 / 1.055, b: 0.055 / 1.055, c: 1 / 12.92, d: 0.04045}
+
+// newProfile creates a Profile for the color space with the given red, green
+// and blue primaries as xy chromaticities, a D65 white point and the same
+// transfer function for each channel.
+func newProfile(name string, primaries [3][2]float64, curve toneCurve) *Profile {
+	var m matrix3
+	for i, p := range primaries {
+		x, y := p[0], p[1]
+		m[0][i], m[1][i], m[2][i] = x/y, 1, (1-x-y)/y
+	}
+	// Scale each primary so that full intensity of all three is white.
+	s := m.inverse().apply([3]float64{0.3127 / 0.3290, 1, (1 - 0.3127 - 0.3290) / 0.3290})
+	for row := range m {
+		for i := range m[row] {
+			m[row][i] *= s[i]
+		}
+	}
+	return &Profile{
+		name:    name,
+		toXYZ:   m,
+		fromXYZ: m.inverse(),
+		curves:  [3]toneCurve{curve, curve, curve},
+	}
+}
+
+// Name returns the description of the profile.
+func (p *Profile) Name() string {
+	return p.name
+}
+
+// String returns the name of the profile.
+func (p *Profile) String() string {
+	return p.name
+}
+
+// isSRGB reports whether the profile needs no conversion at all.
+func (p *Profile) isSRGB() bool {
+	return p == nil || p == ProfileSRGB
+}
+
+// convert converts alpha-premultiplied 16-bit channels encoded in this profile
+// to HCL, reporting false if alpha is 0. It is the equivalent of rgbaToHCL for
+// colors that are not sRGB.
+func (p *Profile) convert(r, g, b, a uint32) (hcl, bool) {
+	if a == 0 {
+		return hcl{}, false
+	}
+	var v [3]float64
+	for i, channel := range [3]uint32{r, g, b} {
+		v[i] = p.curves[i].decode(float64(channel*0xffff/a) / 0xffff)
+	}
+	xyz := p.toXYZ.apply(v)
+	return fromColorful(colorful.Xyz(xyz[0], xyz[1], xyz[2])), true
+}
+
+// Encode returns the given color, which is interpreted as sRGB like all
+// palette colors, as it is encoded in this profile. Colors outside the gamut
+// of the profile are clipped.
+//
+// Encoding palette colors in the profile of the image they were extracted
+// from gives values that match the image's own pixels.
+func (p *Profile) Encode(c color.Color) color.RGBA64 {
+	var col colorful.Color
+	if h, ok := c.(hcl); ok {
+		col = h.colorful()
+	} else if col, ok = colorful.MakeColor(c); !ok {
+		return color.RGBA64{}
+	}
+	if p.isSRGB() {
+		return color.RGBA64Model.Convert(col.Clamped()).(color.RGBA64)
+	}
+	x, y, z := col.Xyz()
+	v := p.fromXYZ.apply([3]float64{x, y, z})
+	var out [3]uint16
+	for i := range v {
+		encoded := p.curves[i].encode(math.Max(0, math.Min(1, v[i])))
+		out[i] = uint16(math.Round(encoded * 0xffff))
+	}
+	return color.RGBA64{out[0], out[1], out[2], 0xffff}
+}
+
+// ParseProfile parses an ICC color profile. Only RGB matrix/TRC profiles,
+// which include the profiles of almost all photos and screenshots, are
+// supported.
+//
+// The profiles of common color spaces are recognized by their contents, and
+// the corresponding predefined Profile, such as ProfileDisplayP3, is returned.
+func ParseProfile(data []byte) (*Profile, error) {
+	if len(data) < 132 {
+		return nil, errors.New("ICC profile is truncated")
+	}
+	if space := string(data[16:20]); space != "RGB " {
+		return nil, fmt.Errorf("unsupported ICC profile color space %q", space)
+	}
+	if pcs := string(data[20:24]); pcs != "XYZ " {
+		return nil, fmt.Errorf("unsupported ICC profile connection space %q", pcs)
+	}
+
+	count := int(binary.BigEndian.Uint32(data[128:]))
+	if count > (len(data)-132)/12 {
+		return nil, errors.New("ICC profile tag table is truncated")
+	}
+	tags := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		entry := data[132+12*i:]
+		offset := binary.BigEndian.Uint32(entry[4:])
+		size := binary.BigEndian.Uint32(entry[8:])
+		if uint64(offset)+uint64(size) > uint64(len(data)) {
+			return nil, fmt.Errorf("ICC profile tag %q is truncated", entry[:4])
+		}
+		tags[string(entry[:4])] = data[offset : offset+size]
+	}
+
+	p := &Profile{name: profileDescription(tags["desc"])}
+	for i, channel := range []string{"r", "g", "b"} {
+		xyz, err := parseXYZTag(tags[channel+"XYZ"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %sXYZ tag: %w", channel, err)
+		}
+		for row := range xyz {
+			p.toXYZ[row][i] = xyz[row]
+		}
+		p.curves[i], err = parseCurveTag(tags[channel+"TRC"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %sTRC tag: %w", channel, err)
+		}
+	}
+	// Colorants are adapted to the D50 illuminant of the profile connection
+	// space, while colors are handled relative to D65 everywhere else.
+	p.toXYZ = bradfordD50ToD65.mul(p.toXYZ)
+	p.fromXYZ = p.toXYZ.inverse()
+
+	for _, known := range knownProfiles {
+		if p.matches(known) {
+			return known, nil
+		}
+	}
+	return p, nil
+}
+
+// matches reports whether two profiles describe the same color space, within
+// the precision of the fixed point numbers in ICC profiles.
+func (p *Profile) matches(other *Profile) bool {
+	const tolerance = 0.002
+	for row := range p.toXYZ {
+		for col := range p.toXYZ[row] {
+			if math.Abs(p.toXYZ[row][col]-other.toXYZ[row][col]) > tolerance {
+				return false
+			}
+		}
+	}
+	for i := range p.curves {
+		for v := 0.0; v <= 1; v += 0.125 {
+			if math.Abs(p.curves[i].decode(v)-other.curves[i].decode(v)) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// profileJSON is the JSON representation of a Profile.
+type profileJSON struct {
+	Name   string           `json:"name"`
+	ToXYZ  matrix3          `json:"to_xyz"`
+	Curves [3]toneCurveJSON `json:"curves"`
+}
+
+// toneCurveJSON is the JSON representation of a toneCurve: either the g, a, b,
+// c, d, e and f parameters of a parametricCurve, or a tableCurve.
+type toneCurveJSON struct {
+	Parametric *[7]float64 `json:"parametric,omitempty"`
+	Table      []float64   `json:"table,omitempty"`
+}
+
+// MarshalJSON encodes the name, the matrix and the tone curves of p, so that
+// profiles read from images, and not only the predefined ones, can be
+// restored by UnmarshalJSON.
+func (p *Profile) MarshalJSON() ([]byte, error) {
+	fields := profileJSON{Name: p.name, ToXYZ: p.toXYZ}
+	for i, curve := range p.curves {
+		switch c := curve.(type) {
+		case parametricCurve:
+			fields.Curves[i].Parametric = &[7]float64{c.g, c.a, c.b, c.c, c.d, c.e, c.f}
+		case tableCurve:
+			fields.Curves[i].Table = c
+		default:
+			return nil, fmt.Errorf("unsupported tone curve %T", curve)
+		}
+	}
+	return json.Marshal(&fields)
+}
+
+// UnmarshalJSON restores a Profile encoded by MarshalJSON. Profiles that match
+// a predefined one, like ProfileDisplayP3, should be replaced by it, as
+// Palette.UnmarshalJSON does, so that they compare equal.
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	var fields profileJSON
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	restored := Profile{name: fields.Name, toXYZ: fields.ToXYZ, fromXYZ: fields.ToXYZ.inverse()}
+	for _, row := range restored.fromXYZ {
+		for _, v := range row {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return errors.New("profile matrix is not invertible")
+			}
+		}
+	}
+	for i, c := range fields.Curves {
+		switch {
+		case c.Parametric != nil:
+			params := c.Parametric
+			if params[0] == 0 || params[1] == 0 {
+				return errors.New("degenerate parametric curve")
+			}
+			restored.curves[i] = parametricCurve{params[0], params[1], params[2], params[3], params[4], params[5], params[6]}
+		case len(c.Table) > 0:
+			restored.curves[i] = tableCurve(c.Table)
+		default:
+			return fmt.Errorf("profile curve %d is missing", i)
+		}
+	}
+	*p = restored
+	return nil
+}
+
+func parseXYZTag(tag []byte) ([3]float64, error) {
+	var xyz [3]float64
+	if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+		return xyz, errors.New("expected an XYZ value")
+	}
+	for i := range xyz {
+		xyz[i] = s15Fixed16(tag[8+4*i:])
+	}
+	return xyz, nil
+}
+
+func parseCurveTag(tag []byte) (toneCurve, error) {
+	if len(tag) < 12 {
+		return nil, errors.New("expected a curve")
+	}
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+2*n {
+			return nil, errors.New("curve is truncated")
+		}
+		switch n {
+		case 0:
+			return parametricCurve{g: 1, a: 1}, nil
+		case 1:
+			return parametricCurve{g: float64(binary.BigEndian.Uint16(tag[12:])) / 256, a: 1}, nil
+		}
+		table := make(tableCurve, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 0xffff
+		}
+		return table, nil
+
+	case "para":
+		// The number of parameters of each function type
+		counts := []int{1, 3, 4, 5, 7}
+		function := int(binary.BigEndian.Uint16(tag[8:]))
+		if function >= len(counts) || len(tag) < 12+4*counts[function] {
+			return nil, fmt.Errorf("unsupported parametric curve type %d", function)
+		}
+		params := make([]float64, counts[function])
+		for i := range params {
+			params[i] = s15Fixed16(tag[12+4*i:])
+		}
+		// Every type is a special case of the last, most general one.
+		c := parametricCurve{g: params[0], a: 1}
+		if function > 0 {
+			c.a = params[1]
+		}
+		// Curves are inverted to encode colors, which takes a nonzero
+		// exponent and slope.
+		if c.g == 0 || c.a == 0 {
+			return nil, errors.New("degenerate parametric curve")
+		}
+		switch function {
+		case 1:
+			c.b = params[2]
+			c.d = -c.b / c.a
+		case 2:
+			c.b, c.e, c.f = params[2], params[3], params[3]
+			c.d = -c.b / c.a
+		case 3:
+			c.b, c.c, c.d = params[2], params[3], params[4]
+		case 4:
+			c.b, c.c, c.d, c.e, c.f = params[2], params[3], params[4], params[5], params[6]
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unsupported curve type %q", tag[:4])
+}
+
+// profileDescription reads the name of a profile from its desc tag, which is a
+// textDescriptionType in version 2 profiles or a multiLocalizedUnicodeType in
+// version 4 profiles.
+func profileDescription(tag []byte) string {
+	if len(tag) < 12 {
+		return "unknown"
+	}
+	switch string(tag[:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) >= 12+n {
+			return strings.TrimRight(string(tag[12:12+n]), "\x00")
+		}
+	case "mluc":
+		if len(tag) < 28 {
+			break
+		}
+		// Use the first record, whatever its language.
+		n := int(binary.BigEndian.Uint32(tag[20:]))
+		offset := int(binary.BigEndian.Uint32(tag[24:]))
+		if offset+n > len(tag) {
+			break
+		}
+		units := make([]uint16, n/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	return "unknown"
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 0x10000
+}
+
+// ReadProfile reads the color profile embedded in a PNG or JPEG image, from
+// PNG iCCP and sRGB chunks or JPEG APP2 markers. Images without an embedded
+// profile, or in other formats, are assumed to be sRGB.
+//
+// Only the beginning of the image is read, up to its pixel data. To decode the
+// image as well, read it into memory first, for example with ioutil.ReadAll.
+func ReadProfile(r io.Reader) (*Profile, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(8)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var data []byte
+	switch {
+	case bytes.HasPrefix(magic, []byte("\x89PNG\r\n\x1a\n")):
+		data, err = readPNGProfile(br)
+	case bytes.HasPrefix(magic, []byte{0xff, 0xd8}):
+		data, err = readJPEGProfile(br)
+	default:
+		return ProfileSRGB, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return ProfileSRGB, nil
+	}
+	return ParseProfile(data)
+}
+
+// maxProfileSize is the size of the largest ICC profile read from an image, well
+// above that of real-world RGB profiles, so that corrupt or malicious files
+// cannot exhaust memory.
+const maxProfileSize = 4 << 20
+
+var errProfileTooLarge = errors.New("embedded color profile is too large")
+
+// readPNGProfile returns the ICC profile embedded in a PNG image, if any.
+func readPNGProfile(r io.Reader) ([]byte, error) {
+	if _, err := io.CopyN(ioutil.Discard, r, 8); err != nil {
+		return nil, err
+	}
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("error reading PNG chunk: %w", err)
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		if length > math.MaxInt32 {
+			return nil, errors.New("invalid PNG chunk length")
+		}
+		switch string(header[4:]) {
+		case "iCCP":
+			if length > maxProfileSize {
+				return nil, errProfileTooLarge
+			}
+			chunk := make([]byte, length)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return nil, fmt.Errorf("error reading PNG iCCP chunk: %w", err)
+			}
+			// The profile name is followed by a null separator and the
+			// compression method, which is always zlib.
+			i := bytes.IndexByte(chunk, 0)
+			if i < 0 || i+2 > len(chunk) {
+				return nil, errors.New("invalid PNG iCCP chunk")
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(chunk[i+2:]))
+			if err != nil {
+				return nil, fmt.Errorf("error decompressing PNG iCCP chunk: %w", err)
+			}
+			data, err := ioutil.ReadAll(io.LimitReader(zr, maxProfileSize+1))
+			if err != nil {
+				return nil, fmt.Errorf("error decompressing PNG iCCP chunk: %w", err)
+			}
+			if len(data) > maxProfileSize {
+				return nil, errProfileTooLarge
+			}
+			return data, nil
+		case "sRGB", "IDAT", "IEND":
+			return nil, nil
+		}
+		// Skip the chunk and its CRC.
+		if _, err := io.CopyN(ioutil.Discard, r, length+4); err != nil {
+			return nil, fmt.Errorf("error reading PNG chunk: %w", err)
+		}
+	}
+}
+
+// readJPEGProfile returns the ICC profile embedded in a JPEG image, if any.
+// Profiles too large for a single marker segment are split across several,
+// which are numbered so that they can be reassembled in order.
+func readJPEGProfile(r io.Reader) ([]byte, error) {
+	if _, err := io.CopyN(ioutil.Discard, r, 2); err != nil {
+		return nil, err
+	}
+	type chunk struct {
+		seq  byte
+		data []byte
+	}
+	var chunks []chunk
+	size := 0
+	var header [2]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("error reading JPEG marker: %w", err)
+		}
+		if header[0] != 0xff {
+			return nil, errors.New("invalid JPEG marker")
+		}
+		// Markers may be preceded by any number of 0xff fill bytes.
+		marker := header[1]
+		for marker == 0xff {
+			if _, err := io.ReadFull(r, header[1:]); err != nil {
+				return nil, fmt.Errorf("error reading JPEG marker: %w", err)
+			}
+			marker = header[1]
+		}
+		// Start of scan, after which there are no more metadata segments,
+		// or end of image.
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("error reading JPEG segment: %w", err)
+		}
+		length := int(binary.BigEndian.Uint16(header[:])) - 2
+		if length < 0 {
+			return nil, errors.New("invalid JPEG segment length")
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, fmt.Errorf("error reading JPEG segment: %w", err)
+		}
+		const iccSignature = "ICC_PROFILE\x00"
+		if marker == 0xe2 && len(segment) >= 14 && string(segment[:12]) == iccSignature {
+			if size += length - 14; size > maxProfileSize {
+				return nil, errProfileTooLarge
+			}
+			chunks = append(chunks, chunk{seq: segment[12], data: segment[14:]})
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].seq < chunks[j].seq })
+	var data []byte
+	for _, c := range chunks {
+		data = append(data, c.data...)
+	}
+	return data, nil
+}
+
+// A toneCurve converts between the encoded values of a color channel and
+// linear light, both in the interval [0, 1].
+type toneCurve interface {
+	decode(v float64) float64
+	encode(v float64) float64
+}
+
+// A parametricCurve is the most general ICC parametric curve:
+//
+//	Y = (aX + b)^g + e  for X >= d
+//	Y = cX + f          for X < d
+type parametricCurve struct {
+	g, a, b, c, d, e, f float64
+}
+
+func (p parametricCurve) decode(v float64) float64 {
+	if v < p.d {
+		return p.c*v + p.f
+	}
+	return math.Pow(math.Max(0, p.a*v+p.b), p.g) + p.e
+}
+
+func (p parametricCurve) encode(v float64) float64 {
+	if v < p.decode(p.d) {
+		if p.c == 0 {
+			return 0
+		}
+		return (v - p.f) / p.c
+	}
+	return (math.Pow(math.Max(0, v-p.e), 1/p.g) - p.b) / p.a
+}
+
+// A tableCurve is a curve sampled at evenly spaced encoded values, and
+// linearly interpolated between them. The table must be monotonic.
+type tableCurve []float64
+
+func (t tableCurve) decode(v float64) float64 {
+	pos := math.Max(0, math.Min(1, v)) * float64(len(t)-1)
+	i := int(pos)
+	if i >= len(t)-1 {
+		return t[len(t)-1]
+	}
+	frac := pos - float64(i)
+	return t[i]*(1-frac) + t[i+1]*frac
+}
+
+func (t tableCurve) encode(v float64) float64 {
+	i := sort.SearchFloat64s(t, v)
+	switch {
+	case i == 0:
+		return 0
+	case i >= len(t):
+		return 1
+	}
+	// Quantized tables often repeat values, especially near 0. Interpolate
+	// from the start of such a flat run, so that values just above it are not
+	// all encoded as its end.
+	start := sort.SearchFloat64s(t[:i], t[i-1])
+	frac := (v - t[i-1]) / (t[i] - t[i-1])
+	return (float64(start) + frac*float64(i-start)) / float64(len(t)-1)
+}
+
+type matrix3 [3][3]float64
+
+// bradfordD50ToD65 adapts XYZ colors from the D50 illuminant to D65 with the
+// Bradford transform.
+var bradfordD50ToD65 = func() matrix3 {
+	bradford := matrix3{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+	d50 := bradford.apply([3]float64{0.9642, 1, 0.8249})
+	d65 := bradford.apply([3]float64{0.95047, 1, 1.08883})
+	var scale matrix3
+	for i := range scale {
+		scale[i][i] = d65[i] / d50[i]
+	}
+	return bradford.inverse().mul(scale).mul(bradford)
+}()
+
+func (m matrix3) apply(v [3]float64) [3]float64 {
+	var out [3]float64
+	for i := range m {
+		out[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2]
+	}
+	return out
+}
+
+func (m matrix3) mul(other matrix3) matrix3 {
+	var out matrix3
+	for i := range m {
+		for j := range other[0] {
+			for k := range other {
+				out[i][j] += m[i][k] * other[k][j]
+			}
+		}
+	}
+	return out
+}
+
+func (m matrix3) inverse() matrix3 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	return matrix3{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det,
+		},
+	}
+}
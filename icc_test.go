@@ -0,0 +1,304 @@
+package palettor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildICC encodes a minimal version 4 matrix/TRC ICC profile for the given
+// profile, with its colorants adapted to D50 as real profiles are.
+func buildICC(p *Profile, desc string, curve []byte) []byte {
+	colorants := bradfordD50ToD65.inverse().mul(p.toXYZ)
+
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	var tags []tag
+	for i, channel := range []string{"r", "g", "b"} {
+		xyz := []byte("XYZ \x00\x00\x00\x00")
+		for row := range colorants {
+			xyz = appendUint32(xyz, uint32(int32(colorants[row][i]*0x10000+0.5)))
+		}
+		tags = append(tags, tag{channel + "XYZ", xyz}, tag{channel + "TRC", curve})
+	}
+	units := utf16.Encode([]rune(desc))
+	mluc := []byte("mluc\x00\x00\x00\x00")
+	mluc = appendUint32(mluc, 1)
+	mluc = appendUint32(mluc, 12)
+	mluc = append(mluc, "enUS"...)
+	mluc = appendUint32(mluc, uint32(2*len(units)))
+	mluc = appendUint32(mluc, 28)
+	for _, u := range units {
+		mluc = append(mluc, byte(u>>8), byte(u))
+	}
+	tags = append(tags, tag{"desc", mluc})
+
+	header := make([]byte, 128)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB XYZ ")
+	copy(header[36:], "acsp")
+	table := appendUint32(nil, uint32(len(tags)))
+	var body []byte
+	offset := 128 + 4 + 12*len(tags)
+	for _, t := range tags {
+		table = append(table, t.sig...)
+		table = appendUint32(table, uint32(offset+len(body)))
+		table = appendUint32(table, uint32(len(t.data)))
+		body = append(body, t.data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	data := append(append(header, table...), body...)
+	binary.BigEndian.PutUint32(data, uint32(len(data)))
+	return data
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func fixed16(v float64) []byte {
+	return appendUint32(nil, uint32(int32(v*0x10000+0.5)))
+}
+
+// srgbCurveTag encodes the sRGB transfer function as a parametric curve.
+func srgbCurveTag() []byte {
+	tag := []byte("para\x00\x00\x00\x00\x00\x03\x00\x00")
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		tag = append(tag, fixed16(v)...)
+	}
+	return tag
+}
+
+// gammaCurveTag encodes a pure gamma curve.
+func gammaCurveTag(gamma float64) []byte {
+	u := uint16(gamma*256 + 0.5)
+	return append([]byte("curv\x00\x00\x00\x00\x00\x00\x00\x01"), byte(u>>8), byte(u))
+}
+
+// tableCurveTag encodes a pure gamma curve as a sampled table.
+func tableCurveTag(gamma float64, n int) []byte {
+	tag := appendUint32([]byte("curv\x00\x00\x00\x00"), uint32(n))
+	for i := 0; i < n; i++ {
+		v := parametricCurve{g: gamma, a: 1}.decode(float64(i) / float64(n-1))
+		u := uint16(v*0xffff + 0.5)
+		tag = append(tag, byte(u>>8), byte(u))
+	}
+	return tag
+}
+
+// withICCChunk inserts an iCCP chunk holding the given profile into a PNG.
+func withICCChunk(t *testing.T, pngData, profile []byte) []byte {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(profile)
+	assert.NoError(t, zw.Close())
+	data := append([]byte("icc\x00\x00"), compressed.Bytes()...)
+	return withPNGChunk(pngData, "iCCP", data)
+}
+
+// withPNGChunk inserts a chunk into a PNG, right after its IHDR chunk.
+func withPNGChunk(pngData []byte, kind string, data []byte) []byte {
+	chunk := appendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	chunk = appendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	// The signature and the 25 byte IHDR chunk
+	const ihdrEnd = 8 + 25
+	return append(append(append([]byte{}, pngData[:ihdrEnd]...), chunk...), pngData[ihdrEnd:]...)
+}
+
+// withICCMarkers inserts the given profile into a JPEG, split across APP2
+// markers that are deliberately out of order.
+func withICCMarkers(jpegData, profile []byte) []byte {
+	half := len(profile) / 2
+	var markers []byte
+	for _, part := range []struct {
+		seq  byte
+		data []byte
+	}{{2, profile[half:]}, {1, profile[:half]}} {
+		segment := append([]byte("ICC_PROFILE\x00"), part.seq, 2)
+		segment = append(segment, part.data...)
+		length := len(segment) + 2
+		markers = append(markers, 0xff, 0xe2, byte(length>>8), byte(length))
+		markers = append(markers, segment...)
+	}
+	return append(append(append([]byte{}, jpegData[:2]...), markers...), jpegData[2:]...)
+}
+
+// withFillBytes inserts 0xff fill bytes before the first marker after the
+// start of a JPEG image.
+func withFillBytes(jpegData []byte) []byte {
+	return append(append(append([]byte{}, jpegData[:2]...), 0xff, 0xff), jpegData[2:]...)
+}
+
+func uniformImage(c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestParseProfileKnown(t *testing.T) {
+	for _, tc := range []struct {
+		profile *Profile
+		curve   []byte
+	}{
+		{ProfileSRGB, srgbCurveTag()},
+		{ProfileDisplayP3, srgbCurveTag()},
+		{ProfileAdobeRGB, gammaCurveTag(563.0 / 256)},
+	} {
+		parsed, err := ParseProfile(buildICC(tc.profile, "some vendor's name", tc.curve))
+		assert.NoError(t, err)
+		assert.True(t, tc.profile == parsed, "expected %v, got %v", tc.profile, parsed)
+	}
+}
+
+func TestParseProfileCustom(t *testing.T) {
+	// Rec. 2020 primaries with a plain gamma
+	rec2020 := newProfile("Rec. 2020", [3][2]float64{{0.708, 0.292}, {0.170, 0.797}, {0.131, 0.046}}, nil)
+	parsed, err := ParseProfile(buildICC(rec2020, "Rec. 2020 Gamma 2.2", tableCurveTag(2.2, 1024)))
+	assert.NoError(t, err)
+	assert.Equal(t, "Rec. 2020 Gamma 2.2", parsed.Name())
+	for _, known := range knownProfiles {
+		assert.False(t, known == parsed)
+	}
+
+	// Colors survive conversion to HCL and back into the profile
+	for _, c := range []color.RGBA64{
+		{0xffff, 0, 0, 0xffff},
+		{0x1234, 0x89ab, 0xcdef, 0xffff},
+		{0x8000, 0x8000, 0x8000, 0xffff},
+	} {
+		converted, ok := parsed.convert(c.RGBA())
+		assert.True(t, ok)
+		actual := parsed.Encode(converted)
+		assert.InDelta(t, c.R, actual.R, 2)
+		assert.InDelta(t, c.G, actual.G, 2)
+		assert.InDelta(t, c.B, actual.B, 2)
+	}
+}
+
+func TestParseProfileInvalid(t *testing.T) {
+	_, err := ParseProfile([]byte("too short"))
+	assert.Error(t, err)
+
+	data := buildICC(ProfileSRGB, "sRGB", srgbCurveTag())
+	copy(data[16:], "CMYK")
+	_, err = ParseProfile(data)
+	assert.Error(t, err, "only RGB profiles are supported")
+
+	data = buildICC(ProfileSRGB, "sRGB", []byte("sf32\x00\x00\x00\x00\x00\x00\x00\x00"))
+	_, err = ParseProfile(data)
+	assert.Error(t, err, "unsupported curve types are rejected")
+
+	// A type 1 parametric curve with a zero slope cannot be inverted
+	degenerate := []byte("para\x00\x00\x00\x00\x00\x01\x00\x00")
+	for _, v := range []float64{2.2, 0, 0} {
+		degenerate = append(degenerate, fixed16(v)...)
+	}
+	_, err = ParseProfile(buildICC(ProfileSRGB, "sRGB", degenerate))
+	assert.Error(t, err, "degenerate curves are rejected")
+}
+
+func TestReadProfile(t *testing.T) {
+	img := uniformImage(color.NRGBA{200, 30, 40, 255})
+	icc := buildICC(ProfileDisplayP3, "Display P3", srgbCurveTag())
+
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, img))
+	var jpegData bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&jpegData, img, nil))
+
+	for name, tc := range map[string]struct {
+		data     []byte
+		expected *Profile
+	}{
+		"untagged png":  {pngData.Bytes(), ProfileSRGB},
+		"untagged jpeg": {jpegData.Bytes(), ProfileSRGB},
+		"png iCCP":      {withICCChunk(t, pngData.Bytes(), icc), ProfileDisplayP3},
+		"png sRGB":      {withPNGChunk(pngData.Bytes(), "sRGB", []byte{0}), ProfileSRGB},
+		"jpeg APP2":     {withICCMarkers(jpegData.Bytes(), icc), ProfileDisplayP3},
+		"jpeg fill":     {withFillBytes(withICCMarkers(jpegData.Bytes(), icc)), ProfileDisplayP3},
+		"other format":  {[]byte("GIF89a"), ProfileSRGB},
+	} {
+		profile, err := ReadProfile(bytes.NewReader(tc.data))
+		assert.NoError(t, err, name)
+		assert.True(t, tc.expected == profile, "%s: expected %v, got %v", name, tc.expected, profile)
+
+		// The image itself still decodes
+		_, _, err = image.Decode(bytes.NewReader(tc.data))
+		if name != "other format" {
+			assert.NoError(t, err, name)
+		}
+	}
+}
+
+func TestReadProfileTooLarge(t *testing.T) {
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, uniformImage(color.White)))
+
+	// A chunk length beyond what PNG allows
+	data := append([]byte{}, pngData.Bytes()[:33]...)
+	data = append(data, 0xff, 0xff, 0xff, 0xff)
+	data = append(data, "iCCP"...)
+	_, err := ReadProfile(bytes.NewReader(data))
+	assert.Error(t, err)
+
+	// A small chunk that decompresses into a huge profile
+	huge := make([]byte, maxProfileSize+1)
+	_, err = ReadProfile(bytes.NewReader(withICCChunk(t, pngData.Bytes(), huge)))
+	assert.Equal(t, errProfileTooLarge, err)
+}
+
+func TestExtractWithSourceProfile(t *testing.T) {
+	// Pure Display P3 red is more saturated than any sRGB color
+	img := uniformImage(color.NRGBA{255, 0, 0, 255})
+	palette, err := Extract(1, 10, img, WithSourceProfile(ProfileDisplayP3))
+	assert.NoError(t, err)
+	assert.Equal(t, ProfileDisplayP3, palette.SourceProfile())
+
+	p3Red := palette.Colors()[0].(hcl)
+	assert.Greater(t, p3Red.c, red.c)
+	assert.Equal(t, color.RGBA64{0xffff, 0, 0, 0xffff}, ProfileDisplayP3.Encode(p3Red))
+
+	// Without the profile, the pixels are read as sRGB
+	palette, err = Extract(1, 10, img)
+	assert.NoError(t, err)
+	assert.Equal(t, ProfileSRGB, palette.SourceProfile())
+	assert.InDelta(t, red.c, palette.Colors()[0].(hcl).c, 0.0001)
+
+	// Cached conversions are specific to the profile
+	cache := NewCache(10)
+	srgb, err := Extract(1, 10, img, WithCache(cache))
+	assert.NoError(t, err)
+	p3, err := Extract(1, 10, img, WithCache(cache), WithSourceProfile(ProfileDisplayP3))
+	assert.NoError(t, err)
+	assert.NotEqual(t, srgb.Colors(), p3.Colors())
+	assert.Equal(t, 2, cache.Stats().Entries)
+}
+
+func TestProfileEncodeSRGB(t *testing.T) {
+	c := color.RGBA64{0x1234, 0x89ab, 0xcdef, 0xffff}
+	h, err := toHCL(c)
+	assert.NoError(t, err)
+	assert.Equal(t, c, ProfileSRGB.Encode(h))
+	assert.Equal(t, c, ProfileSRGB.Encode(c))
+
+	// Grey is grey in every profile
+	grey := ProfileAdobeRGB.Encode(color.Gray{128})
+	assert.InDelta(t, grey.R, grey.G, 2)
+	assert.InDelta(t, grey.G, grey.B, 2)
+}
@@ -10,27 +10,39 @@ import (
 // k-means clustering algorithm. It returns a Palette, after running the
 // algorithm up to maxIterations times.
 //
+// Each color contributes to the weight of its cluster, and to the position of
+// its centroid, in proportion to its weight. A nil weights slice weights all
+// colors equally. Centroids are found by averaging colors in the given space.
+//
 // Note: in terms of the standard algorithm[1], an observation in this
-// implementation is simply a color, and we use the RGB channels as Euclidean
-// coordinates for the purposes of finding the distance between two colors.
+// implementation is simply a color, and the distance between two colors is
+// their Euclidean distance in L*a*b* space, with the hue difference scaled by
+// chroma (see hcl.distanceSquared).
 //
 // [1]: https://en.wikipedia.org/wiki/K-means_clustering#Standard_algorithm
-func clusterColors(k, maxIterations int, colors []hcl) (*Palette, error) {
+func clusterColors(k, maxIterations int, colors []hcl, weights []float64, space BlendSpace) (*Palette, error) {
 	colorCount := len(colors)
 	if colorCount < k {
 		return nil, fmt.Errorf("too few colors for k (%d < %d)", colorCount, k)
 	}
 
-	centroids := initializeStep(k, colors)
-	var clusters map[hcl][]hcl
+	palette := clusterFrom(initializeStep(k, colors), maxIterations, colors, weights, space)
+	palette.k = k
+	return palette, nil
+}
+
+// clusterFrom runs the k-means algorithm from the given initial centroids, up
+// to maxIterations times, and builds a Palette from the resulting clusters.
+func clusterFrom(centroids []hcl, maxIterations int, colors []hcl, weights []float64, space BlendSpace) *Palette {
+	var clusters map[hcl]*cluster
 	var converged bool
 
 	// The algorithm isn't guaranteed to converge, so we put a limit on the
 	// number of attempts we will make.
 	var iterations int
 	for iterations = 0; iterations < maxIterations; iterations++ {
-		clusters = assignmentStep(centroids, colors)
-		converged, centroids = updateStep(clusters)
+		clusters = assignmentStep(centroids, colors, weights)
+		converged, centroids = updateStep(clusters, space)
 		if converged {
 			break
 		}
@@ -38,13 +50,19 @@ func clusterColors(k, maxIterations int, colors []hcl) (*Palette, error) {
 
 	// Build palette.
 	palette := &Palette{
+		k:          len(centroids),
 		iterations: iterations,
 		converged:  converged,
+		blendSpace: space,
+	}
+	var totalWeight float64
+	for _, cluster := range clusters {
+		totalWeight += cluster.weight
 	}
 	for centroid, cluster := range clusters {
-		palette.add(centroid, float64(len(cluster))/float64(colorCount))
+		palette.add(centroid, cluster.weight/totalWeight)
 	}
-	return palette, nil
+	return palette
 }
 
 // Generate the initial list of k centroids from the given list of colors.
@@ -73,29 +91,45 @@ func initializeStep(k int, colors []hcl) []hcl {
 	return centroids
 }
 
+// A cluster is the group of colors assigned to a centroid, along with their
+// weights and the sum of those weights.
+type cluster struct {
+	colors  []hcl
+	weights []float64 // nil if all colors are weighted equally
+	weight  float64
+}
+
 // Assign each color to the cluster of the closest centroid.
-func assignmentStep(centroids, colors []hcl) map[hcl][]hcl {
-	clusters := make(map[hcl][]hcl)
-	for _, x := range colors {
+func assignmentStep(centroids, colors []hcl, weights []float64) map[hcl]*cluster {
+	clusters := make(map[hcl]*cluster)
+	for i, x := range colors {
 		centroid := nearest(x, centroids)
-		cluster, found := clusters[centroid]
+		c, found := clusters[centroid]
 		if !found {
-			// allocate slice w/ maximum possible capacity to avoid possible
+			// allocate slices w/ maximum possible capacity to avoid possible
 			// allocations per-append below
-			cluster = make([]hcl, 0, len(colors))
+			c = &cluster{colors: make([]hcl, 0, len(colors))}
+			if weights != nil {
+				c.weights = make([]float64, 0, len(colors))
+			}
+			clusters[centroid] = c
+		}
+		c.colors = append(c.colors, x)
+		if weights != nil {
+			c.weights = append(c.weights, weights[i])
 		}
-		clusters[centroid] = append(cluster, x)
+		c.weight += weightAt(weights, i)
 	}
 	return clusters
 }
 
 // Pick new centroids from each cluster. If none of the centroids change, the
 // clusters have stabilized and the algorithm has converged.
-func updateStep(clusters map[hcl][]hcl) (bool, []hcl) {
+func updateStep(clusters map[hcl]*cluster, space BlendSpace) (bool, []hcl) {
 	converged := true
 	newCentroids := make([]hcl, 0, len(clusters))
 	for centroid, cluster := range clusters {
-		newCentroid := findCentroid(cluster)
+		newCentroid := findCentroid(cluster.colors, cluster.weights, space)
 		if newCentroid != centroid {
 			converged = false
 		}
@@ -104,13 +138,14 @@ func updateStep(clusters map[hcl][]hcl) (bool, []hcl) {
 	return converged, newCentroids
 }
 
-// Find the color closest to the mean of the given colors.
+// Find the color closest to the weighted mean of the given colors, as
+// averaged in the given space.
 //
 // Note: I think this is a departure from the "standard" algorithm, which seems
 // to instead use the actual mean of the given colors (which is likely
 // not actually present in those colors).
-func findCentroid(colors []hcl) hcl {
-	center := mean(colors)
+func findCentroid(colors []hcl, weights []float64, space BlendSpace) hcl {
+	center := space.mean(colors, weights)
 	return nearest(center, colors)
 }
 
@@ -39,7 +39,7 @@ func TestNearest(t *testing.T) {
 
 func TestFindCentroid(t *testing.T) {
 	var cluster = []hcl{black, white, red, mostlyRed}
-	centroid := findCentroid(cluster)
+	centroid := findCentroid(cluster, nil, BlendHCL)
 
 	assert.Contains(t, cluster, centroid, "centroid should be a member of the cluster")
 }
@@ -48,27 +48,129 @@ func TestCluster(t *testing.T) {
 	var colors = []hcl{black, white, red}
 
 	k := 4
-	_, err := clusterColors(k, 100, colors)
+	_, err := clusterColors(k, 100, colors, nil, BlendHCL)
 	assert.Error(t, err, "too few colors should result in an error")
 
 	k = 3
-	palette, err := clusterColors(k, 100, colors)
+	palette, err := clusterColors(k, 100, colors, nil, BlendHCL)
 	assert.NoError(t, err)
 	assert.Equal(t, k, palette.Count(), "got unexpected number of clusters")
 
 	k = 2
 	colors = []hcl{black, white}
-	palette, _ = clusterColors(k, 100, colors)
+	palette, _ = clusterColors(k, 100, colors, nil, BlendHCL)
 	assert.Equal(t, 0.5, palette.Weight(black), "expected weight of black cluster to be 0.5")
 	assert.Equal(t, 0.5, palette.Weight(white), "expected weight of white cluster to be 0.5")
 
 	// If there are not enough unique colors to cluster, it's okay for the size
 	// of the extracted palette to be < k
 	k = 3
-	palette, _ = clusterColors(k, 100, []hcl{black, black, black, black, black, white})
+	palette, _ = clusterColors(k, 100, []hcl{black, black, black, black, black, white}, nil, BlendHCL)
 	assert.LessOrEqual(t, palette.Count(), 2, "actual palette can be smaller than k")
 }
 
+func TestClusterWeighted(t *testing.T) {
+	colors := []hcl{black, white}
+	weights := []float64{3, 1}
+
+	palette, err := clusterColors(2, 100, colors, weights, BlendHCL)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.75, palette.Weight(black), 0.0001, "cluster weight should be the sum of its colors' weights")
+	assert.InDelta(t, 0.25, palette.Weight(white), 0.0001, "cluster weight should be the sum of its colors' weights")
+}
+
+func TestFindCentroidWeighted(t *testing.T) {
+	var cluster = []hcl{black, darkGrey, white}
+	assert.Equal(t, white, findCentroid(cluster, []float64{0.1, 0.1, 10}, BlendHCL), "heavy colors should pull the centroid")
+	assert.Equal(t, darkGrey, findCentroid(cluster, []float64{10, 10, 0.1}, BlendHCL), "heavy colors should pull the centroid")
+}
+
+// noisyImage returns an image whose pixels are drawn from the given base
+// colors, each occupying an equal vertical band, with up to noise added to or
+// subtracted from each channel independently.
+func noisyImage(noise int, bases ...color.RGBA) *image.RGBA {
+	const bandWidth, height = 20, 20
+	img := image.NewRGBA(image.Rect(0, 0, bandWidth*len(bases), height))
+	jitter := func(v uint8) uint8 {
+		n := int(v) + r.Intn(2*noise+1) - noise
+		if n < 0 {
+			n = 0
+		} else if n > 255 {
+			n = 255
+		}
+		return uint8(n)
+	}
+	for i, base := range bases {
+		for y := 0; y < height; y++ {
+			for x := i * bandWidth; x < (i+1)*bandWidth; x++ {
+				img.Set(x, y, color.RGBA{jitter(base.R), jitter(base.G), jitter(base.B), 255})
+			}
+		}
+	}
+	return img
+}
+
+// assertBands checks that a palette extracted from a noisyImage found one
+// entry per band, each close to its band's base color.
+func assertBands(t *testing.T, palette *Palette, bases ...color.RGBA) {
+	t.Helper()
+	entries := palette.EntriesBy(ByLightness)
+	if !assert.Len(t, entries, len(bases)) {
+		return
+	}
+	for i, entry := range entries {
+		assert.InDelta(t, 1/float64(len(bases)), entry.Weight, 0.0001, "each band should be its own cluster")
+		assert.Less(t, entryHCL(entry).deltaE(forceHCL(bases[i])), 5.0, "cluster should match band color")
+	}
+}
+
+// clusterBands clusters the pixels of a noisyImage, starting from one noisy
+// pixel of each band rather than from random centroids, which may all land in
+// the same band.
+func clusterBands(t *testing.T, img *image.RGBA, bands int) *Palette {
+	t.Helper()
+	colors, _, err := getColors(img, newConfig(nil))
+	assert.NoError(t, err)
+	bandWidth := img.Bounds().Dx() / bands
+	centroids := make([]hcl, bands)
+	for i := range centroids {
+		// A pixel from the middle of the first row of each band
+		centroids[i] = colors[i*bandWidth+bandWidth/2]
+	}
+	return clusterFrom(centroids, 100, colors, nil, BlendHCL)
+}
+
+func TestClusterGreyscale(t *testing.T) {
+	// Near-black pixels have essentially random hues, which must not split
+	// them into separate clusters.
+	bases := []color.RGBA{{4, 4, 4, 255}, {60, 60, 60, 255}}
+	for i := 0; i < 5; i++ {
+		assertBands(t, clusterBands(t, noisyImage(4, bases...), len(bases)), bases...)
+	}
+
+	// Given ideal centroids, every pixel is assigned to the centroid of its
+	// own band, regardless of hue.
+	bases = append(bases, color.RGBA{230, 230, 230, 255})
+	img := noisyImage(4, bases...)
+	colors, _, err := getColors(img, newConfig(nil))
+	assert.NoError(t, err)
+	centroids := []hcl{forceHCL(bases[0]), forceHCL(bases[1]), forceHCL(bases[2])}
+	clusters := assignmentStep(centroids, colors, nil)
+	for _, centroid := range centroids {
+		if assert.Contains(t, clusters, centroid) {
+			assert.Len(t, clusters[centroid].colors, len(colors)/3)
+		}
+	}
+}
+
+func TestClusterLowSaturation(t *testing.T) {
+	// Faint tints at the same lightness should still be told apart.
+	bases := []color.RGBA{{150, 130, 130, 255}, {175, 175, 190, 255}}
+	for i := 0; i < 5; i++ {
+		assertBands(t, clusterBands(t, noisyImage(3, bases...), len(bases)), bases...)
+	}
+}
+
 func BenchmarkClusterColors200x200(b *testing.B) {
 	reader, err := os.Open("testdata/resized.jpg")
 	if err != nil {
@@ -81,14 +183,14 @@ func BenchmarkClusterColors200x200(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	colors, err := getColors(img)
+	colors, _, err := getColors(img, newConfig(nil))
 	if err != nil {
 		b.Fatal(err)
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if _, err := clusterColors(4, 100, colors); err != nil {
+		if _, err := clusterColors(4, 100, colors, nil, BlendHCL); err != nil {
 			b.Error(err)
 		}
 	}
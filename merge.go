@@ -0,0 +1,57 @@
+package palettor
+
+// Merge returns a new Palette in which entries whose colors differ by less
+// than the given CIEDE2000 ΔE have been combined. Merged entries carry the sum
+// of their weights, and their color is the weighted mean of the originals,
+// averaged in the BlendSpace the palette was extracted with.
+//
+// Entries are merged greedily, closest pair first, until no remaining pair is
+// closer than the threshold. The receiver is not modified.
+func (p *Palette) Merge(threshold float64) *Palette {
+	type group struct {
+		color   hcl
+		members []hcl
+		weights []float64
+		weight  float64
+	}
+
+	entries := p.Entries()
+	groups := make([]*group, len(entries))
+	for i, entry := range entries {
+		c := entryHCL(entry)
+		groups[i] = &group{
+			color:   c,
+			members: []hcl{c},
+			weights: []float64{entry.Weight},
+			weight:  entry.Weight,
+		}
+	}
+
+	for len(groups) > 1 {
+		a, b := -1, -1
+		minDist := threshold
+		for i := range groups {
+			for j := i + 1; j < len(groups); j++ {
+				if dist := groups[i].color.deltaE(groups[j].color); dist < minDist {
+					minDist, a, b = dist, i, j
+				}
+			}
+		}
+		if a == -1 {
+			break
+		}
+
+		merged := groups[a]
+		merged.members = append(merged.members, groups[b].members...)
+		merged.weights = append(merged.weights, groups[b].weights...)
+		merged.weight += groups[b].weight
+		merged.color = p.blendSpace.mean(merged.members, merged.weights)
+		groups = append(groups[:b], groups[b+1:]...)
+	}
+
+	result := p.derive()
+	for _, g := range groups {
+		result.add(g.color, g.weight)
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var nearBlues = []color.RGBA{
+	{20, 40, 200, 255},
+	{22, 41, 201, 255},
+	{19, 38, 198, 255},
+	{21, 42, 203, 255},
+}
+
+func TestMerge(t *testing.T) {
+	palette := &Palette{converged: true, iterations: 3}
+	for _, c := range nearBlues {
+		palette.add(forceHCL(c), 0.2)
+	}
+	palette.add(red, 0.2)
+
+	merged := palette.Merge(5)
+	assert.Equal(t, 2, merged.Count(), "near-identical blues should be merged")
+	assert.Equal(t, 5, palette.Count(), "original palette should not be modified")
+	assert.Equal(t, palette.Converged(), merged.Converged())
+	assert.Equal(t, palette.Iterations(), merged.Iterations())
+
+	entries := merged.Entries()
+	assert.InDelta(t, 0.2, entries[0].Weight, 0.0001)
+	assert.Equal(t, red, entries[0].Color)
+	assert.InDelta(t, 0.8, entries[1].Weight, 0.0001, "merged entry should carry the sum of the weights")
+	mergedBlue := entries[1].Color.(hcl)
+	for _, c := range nearBlues {
+		assert.Less(t, mergedBlue.deltaE(forceHCL(c)), 5.0)
+	}
+
+	assert.Equal(t, 5, palette.Merge(0).Count(), "zero threshold should not merge anything")
+	assert.Equal(t, 1, palette.Merge(1000).Count(), "huge threshold should merge everything")
+}
+
+func TestExtractWithMergeThreshold(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 1))
+	for x, c := range nearBlues {
+		img.Set(x, 0, c)
+	}
+	img.Set(4, 0, color.RGBA{255, 0, 0, 255})
+
+	palette, err := Extract(5, 100, img)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, palette.Count())
+
+	palette, err = Extract(5, 100, img, WithMergeThreshold(5))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, palette.Count())
+}
@@ -0,0 +1,288 @@
+package palettor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// cssColors are the named colors of CSS Color Module Level 4, excluding the
+// alternative "grey" spellings of the grey colors. Where two names share a
+// color, the first one is preferred.
+var cssColors = []struct {
+	name    string
+	r, g, b uint8
+}{
+	{"aliceblue", 240, 248, 255},
+	{"antiquewhite", 250, 235, 215},
+	{"aqua", 0, 255, 255},
+	{"aquamarine", 127, 255, 212},
+	{"azure", 240, 255, 255},
+	{"beige", 245, 245, 220},
+	{"bisque", 255, 228, 196},
+	{"black", 0, 0, 0},
+	{"blanchedalmond", 255, 235, 205},
+	{"blue", 0, 0, 255},
+	{"blueviolet", 138, 43, 226},
+	{"brown", 165, 42, 42},
+	{"burlywood", 222, 184, 135},
+	{"cadetblue", 95, 158, 160},
+	{"chartreuse", 127, 255, 0},
+	{"chocolate", 210, 105, 30},
+	{"coral", 255, 127, 80},
+	{"cornflowerblue", 100, 149, 237},
+	{"cornsilk", 255, 248, 220},
+	{"crimson", 220, 20, 60},
+	{"cyan", 0, 255, 255},
+	{"darkblue", 0, 0, 139},
+	{"darkcyan", 0, 139, 139},
+	{"darkgoldenrod", 184, 134, 11},
+	{"darkgray", 169, 169, 169},
+	{"darkgreen", 0, 100, 0},
+	{"darkkhaki", 189, 183, 107},
+	{"darkmagenta", 139, 0, 139},
+	{"darkolivegreen", 85, 107, 47},
+	{"darkorange", 255, 140, 0},
+	{"darkorchid", 153, 50, 204},
+	{"darkred", 139, 0, 0},
+	{"darksalmon", 233, 150, 122},
+	{"darkseagreen", 143, 188, 143},
+	{"darkslateblue", 72, 61, 139},
+	{"darkslategray", 47, 79, 79},
+	{"darkturquoise", 0, 206, 209},
+	{"darkviolet", 148, 0, 211},
+	{"deeppink", 255, 20, 147},
+	{"deepskyblue", 0, 191, 255},
+	{"dimgray", 105, 105, 105},
+	{"dodgerblue", 30, 144, 255},
+	{"firebrick", 178, 34, 34},
+	{"floralwhite", 255, 250, 240},
+	{"forestgreen", 34, 139, 34},
+	{"fuchsia", 255, 0, 255},
+	{"gainsboro", 220, 220, 220},
+	{"ghostwhite", 248, 248, 255},
+	{"gold", 255, 215, 0},
+	{"goldenrod", 218, 165, 32},
+	{"gray", 128, 128, 128},
+	{"green", 0, 128, 0},
+	{"greenyellow", 173, 255, 47},
+	{"honeydew", 240, 255, 240},
+	{"hotpink", 255, 105, 180},
+	{"indianred", 205, 92, 92},
+	{"indigo", 75, 0, 130},
+	{"ivory", 255, 255, 240},
+	{"khaki", 240, 230, 140},
+	{"lavender", 230, 230, 250},
+	{"lavenderblush", 255, 240, 245},
+	{"lawngreen", 124, 252, 0},
+	{"lemonchiffon", 255, 250, 205},
+	{"lightblue", 173, 216, 230},
+	{"lightcoral", 240, 128, 128},
+	{"lightcyan", 224, 255, 255},
+	{"lightgoldenrodyellow", 250, 250, 210},
+	{"lightgray", 211, 211, 211},
+	{"lightgreen", 144, 238, 144},
+	{"lightpink", 255, 182, 193},
+	{"lightsalmon", 255, 160, 122},
+	{"lightseagreen", 32, 178, 170},
+	{"lightskyblue", 135, 206, 250},
+	{"lightslategray", 119, 136, 153},
+	{"lightsteelblue", 176, 196, 222},
+	{"lightyellow", 255, 255, 224},
+	{"lime", 0, 255, 0},
+	{"limegreen", 50, 205, 50},
+	{"linen", 250, 240, 230},
+	{"magenta", 255, 0, 255},
+	{"maroon", 128, 0, 0},
+	{"mediumaquamarine", 102, 205, 170},
+	{"mediumblue", 0, 0, 205},
+	{"mediumorchid", 186, 85, 211},
+	{"mediumpurple", 147, 112, 219},
+	{"mediumseagreen", 60, 179, 113},
+	{"mediumslateblue", 123, 104, 238},
+	{"mediumspringgreen", 0, 250, 154},
+	{"mediumturquoise", 72, 209, 204},
+	{"mediumvioletred", 199, 21, 133},
+	{"midnightblue", 25, 25, 112},
+	{"mintcream", 245, 255, 250},
+	{"mistyrose", 255, 228, 225},
+	{"moccasin", 255, 228, 181},
+	{"navajowhite", 255, 222, 173},
+	{"navy", 0, 0, 128},
+	{"oldlace", 253, 245, 230},
+	{"olive", 128, 128, 0},
+	{"olivedrab", 107, 142, 35},
+	{"orange", 255, 165, 0},
+	{"orangered", 255, 69, 0},
+	{"orchid", 218, 112, 214},
+	{"palegoldenrod", 238, 232, 170},
+	{"palegreen", 152, 251, 152},
+	{"paleturquoise", 175, 238, 238},
+	{"palevioletred", 219, 112, 147},
+	{"papayawhip", 255, 239, 213},
+	{"peachpuff", 255, 218, 185},
+	{"peru", 205, 133, 63},
+	{"pink", 255, 192, 203},
+	{"plum", 221, 160, 221},
+	{"powderblue", 176, 224, 230},
+	{"purple", 128, 0, 128},
+	{"rebeccapurple", 102, 51, 153},
+	{"red", 255, 0, 0},
+	{"rosybrown", 188, 143, 143},
+	{"royalblue", 65, 105, 225},
+	{"saddlebrown", 139, 69, 19},
+	{"salmon", 250, 128, 114},
+	{"sandybrown", 244, 164, 96},
+	{"seagreen", 46, 139, 87},
+	{"seashell", 255, 245, 238},
+	{"sienna", 160, 82, 45},
+	{"silver", 192, 192, 192},
+	{"skyblue", 135, 206, 235},
+	{"slateblue", 106, 90, 205},
+	{"slategray", 112, 128, 144},
+	{"snow", 255, 250, 250},
+	{"springgreen", 0, 255, 127},
+	{"steelblue", 70, 130, 180},
+	{"tan", 210, 180, 140},
+	{"teal", 0, 128, 128},
+	{"thistle", 216, 191, 216},
+	{"tomato", 255, 99, 71},
+	{"turquoise", 64, 224, 208},
+	{"violet", 238, 130, 238},
+	{"wheat", 245, 222, 179},
+	{"white", 255, 255, 255},
+	{"whitesmoke", 245, 245, 245},
+	{"yellow", 255, 255, 0},
+	{"yellowgreen", 154, 205, 50},
+}
+
+// A NamedColor is a color in a Dictionary.
+type NamedColor struct {
+	Name  string
+	Color color.Color
+}
+
+// A Dictionary is a list of named colors, after which palette entries are
+// named. Extract names entries after DictionaryCSS by default; other
+// dictionaries can be built with NewDictionary, or read from a file with
+// ReadDictionary, and passed to WithDictionary or Palette.Named.
+type Dictionary struct {
+	name   string
+	names  []string
+	colors []hcl
+}
+
+// DictionaryCSS holds the named colors of CSS, e.g. "steelblue". It is the
+// only predefined Dictionary: larger lists, like the results of the XKCD color
+// survey, are not embedded, and are read with ReadDictionary.
+var DictionaryCSS = newCSSDictionary()
+
+// knownDictionaries are the predefined Dictionaries, which palettes restored
+// from JSON can refer to by name.
+var knownDictionaries = []*Dictionary{DictionaryCSS}
+
+func newCSSDictionary() *Dictionary {
+	colors := make([]NamedColor, len(cssColors))
+	for i, named := range cssColors {
+		colors[i] = NamedColor{named.name, color.RGBA{named.r, named.g, named.b, 0xff}}
+	}
+	return NewDictionary("css", colors)
+}
+
+// NewDictionary returns a Dictionary with the given name and colors. Where two
+// colors are equally close to a palette entry, the earlier one is preferred.
+// Colors that are fully transparent are left out.
+func NewDictionary(name string, colors []NamedColor) *Dictionary {
+	d := &Dictionary{name: name}
+	for _, named := range colors {
+		c, err := toHCL(named.Color)
+		if err != nil {
+			continue
+		}
+		d.names = append(d.names, named.Name)
+		d.colors = append(d.colors, c)
+	}
+	return d
+}
+
+// ReadDictionary reads a Dictionary with the given name from a list of named
+// colors, one per line, each followed by its hex value, as in the results of
+// the XKCD color survey (https://xkcd.com/color/rgb.txt):
+//
+//	cloudy blue	#acc2d9
+//
+// Blank lines, lines starting with "#" and other lines that do not end in a
+// hex value, such as a license notice, are skipped.
+func ReadDictionary(name string, r io.Reader) (*Dictionary, error) {
+	var colors []NamedColor
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		hex := fields[len(fields)-1]
+		if !strings.HasPrefix(hex, "#") {
+			continue
+		}
+		c, err := colorful.Hex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid color %q", line, hex)
+		}
+		colors = append(colors, NamedColor{strings.Join(fields[:len(fields)-1], " "), c})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(colors) == 0 {
+		return nil, errors.New("dictionary has no colors")
+	}
+	return NewDictionary(name, colors), nil
+}
+
+// Name returns the name of a Dictionary.
+func (d *Dictionary) Name() string {
+	return d.name
+}
+
+// String returns the name of a Dictionary.
+func (d *Dictionary) String() string {
+	return d.name
+}
+
+// Len returns the number of colors in a Dictionary.
+func (d *Dictionary) Len() int {
+	return len(d.names)
+}
+
+// Nearest returns the name of the color in the Dictionary closest to c, as
+// measured by CIEDE2000, along with the ΔE between them. It returns an empty
+// name for transparent colors, and for an empty Dictionary.
+func (d *Dictionary) Nearest(c color.Color) (string, float64) {
+	target, err := toHCL(c)
+	if err != nil || len(d.colors) == 0 {
+		return "", 0
+	}
+	best, bestDist := 0, target.deltaE(d.colors[0])
+	for i, candidate := range d.colors[1:] {
+		if dist := target.deltaE(candidate); dist < bestDist {
+			best, bestDist = i+1, dist
+		}
+	}
+	return d.names[best], bestDist
+}
+
+// entryName returns the name of an entry, falling back to the nearest CSS
+// color for entries without one.
+func entryName(e Entry) string {
+	if e.Name != "" {
+		return e.Name
+	}
+	name, _ := DictionaryCSS.Nearest(e.Color)
+	return name
+}
@@ -0,0 +1,111 @@
+package palettor
+
+import (
+	"encoding/json"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDictionaryCSS(t *testing.T) {
+	for expected, c := range map[string]color.Color{
+		"steelblue": color.RGBA{70, 130, 180, 255},
+		"navy":      color.RGBA{2, 3, 120, 255},
+		"black":     color.Black,
+		"white":     color.White,
+		"aqua":      color.RGBA{0, 255, 255, 255}, // not "cyan", which comes later
+		"":          color.Transparent,
+	} {
+		name, _ := DictionaryCSS.Nearest(c)
+		assert.Equal(t, expected, name)
+	}
+
+	name, deltaE := DictionaryCSS.Nearest(color.RGBA{70, 130, 180, 255})
+	assert.Equal(t, "steelblue", name)
+	assert.InDelta(t, 0, deltaE, 0.0001)
+	name, deltaE = DictionaryCSS.Nearest(color.RGBA{72, 128, 182, 255})
+	assert.Equal(t, "steelblue", name)
+	assert.True(t, deltaE > 0)
+}
+
+func TestReadDictionary(t *testing.T) {
+	dictionary, err := ReadDictionary("survey", strings.NewReader(`License: http://creativecommons.org/publicdomain/zero/1.0/
+# a comment
+dusty rose	#c0737a	
+deep  sea   blue #015482
+
+navy	#01153e
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "survey", dictionary.Name())
+	assert.Equal(t, 3, dictionary.Len())
+	for expected, c := range map[string]color.Color{
+		"dusty rose":    color.RGBA{190, 115, 122, 255},
+		"deep sea blue": color.RGBA{0, 80, 130, 255},
+		"navy":          color.RGBA{0, 0, 80, 255},
+	} {
+		name, _ := dictionary.Nearest(c)
+		assert.Equal(t, expected, name)
+	}
+
+	_, err = ReadDictionary("invalid", strings.NewReader("red #ff00zz\n"))
+	assert.Error(t, err)
+	_, err = ReadDictionary("empty", strings.NewReader("License: none\n"))
+	assert.Error(t, err)
+
+	empty := NewDictionary("empty", nil)
+	name, _ := empty.Nearest(color.White)
+	assert.Equal(t, "", name)
+}
+
+func TestExtractNames(t *testing.T) {
+	img := uniformImage(color.NRGBA{70, 130, 180, 255})
+	palette, err := Extract(1, 10, img)
+	assert.NoError(t, err)
+	assert.Equal(t, DictionaryCSS, palette.Dictionary())
+	assert.Equal(t, "steelblue", palette.Entries()[0].Name)
+
+	survey := NewDictionary("survey", []NamedColor{
+		{"dusty rose", color.RGBA{192, 115, 122, 255}},
+		{"dull blue", color.RGBA{73, 117, 156, 255}},
+	})
+	palette, err = Extract(1, 10, img, WithDictionary(survey))
+	assert.NoError(t, err)
+	assert.Equal(t, "dull blue", palette.Entries()[0].Name)
+	for _, e := range palette.entries {
+		assert.Equal(t, "dull blue", e.Name, "names should be stored with the entries")
+	}
+
+	// Names carry through palettes derived from the original
+	assert.Equal(t, "dull blue", palette.Merge(10).Entries()[0].Name)
+	assert.Equal(t, "steelblue", palette.Named(DictionaryCSS).Entries()[0].Name)
+	assert.Equal(t, "dull blue", palette.Entries()[0].Name, "the receiver should not be modified")
+
+	palette, err = Extract(1, 10, img, WithDictionary(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "", palette.Entries()[0].Name)
+}
+
+func TestNamesJSON(t *testing.T) {
+	palette := &Palette{dictionary: NewDictionary("survey", []NamedColor{{"dusty rose", color.RGBA{192, 115, 122, 255}}})}
+	palette.add(forceHCL(color.RGBA{190, 115, 122, 255}), 1)
+
+	data, err := json.Marshal(palette)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"name":"dusty rose"`)
+	assert.Contains(t, string(data), `"dictionary":"survey"`)
+
+	// Names from other dictionaries are restored as recorded
+	var decoded Palette
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Nil(t, decoded.Dictionary())
+	assert.Equal(t, "dusty rose", decoded.Entries()[0].Name)
+
+	data, err = json.Marshal(palette.Named(DictionaryCSS))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, DictionaryCSS, decoded.Dictionary())
+	assert.Equal(t, "indianred", decoded.Entries()[0].Name)
+}
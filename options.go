@@ -0,0 +1,207 @@
+package palettor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// An Option customizes how Extract finds the palette of an image.
+type Option func(*config)
+
+type config struct {
+	sampler sampler
+	cache   *Cache
+	profile *Profile
+
+	dictionary *Dictionary
+
+	region    *image.Rectangle
+	mask      image.Image
+	weighters []weighter
+
+	blendSpace     BlendSpace
+	mergeThreshold float64
+	minWeight      float64
+
+	excludedColors      []exclusion
+	excludeBackground   bool
+	backgroundTolerance float64
+
+	// err is the error of the first invalid option, reported by Extract.
+	err error
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{sampler: fullSampler{}, dictionary: DictionaryCSS}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// converter returns the function used to convert pixels to HCL.
+func (cfg *config) converter() converter {
+	if cfg.cache != nil {
+		return cfg.cache.converter(cfg.profile)
+	}
+	if !cfg.profile.isSRGB() {
+		return cfg.profile.convert
+	}
+	return rgbaToHCL
+}
+
+// WithSourceProfile interprets the pixels of the image as encoded in the given
+// color Profile, rather than sRGB, converting them before clustering. The
+// profile embedded in an image file can be read with ReadProfile.
+//
+// Palette colors are always sRGB, but can be encoded back into the source
+// profile with Profile.Encode.
+func WithSourceProfile(profile *Profile) Option {
+	return func(cfg *config) {
+		cfg.profile = profile
+	}
+}
+
+// WithDictionary names palette entries after the nearest colors in the given
+// Dictionary, rather than DictionaryCSS, or leaves them unnamed if it is nil.
+func WithDictionary(dictionary *Dictionary) Option {
+	return func(cfg *config) {
+		cfg.dictionary = dictionary
+	}
+}
+
+// WithCache memoizes the conversion of pixel colors using the given Cache,
+// which may be shared across any number of concurrent Extract calls.
+func WithCache(cache *Cache) Option {
+	return func(cfg *config) {
+		cfg.cache = cache
+	}
+}
+
+// WithGridSampling considers only every stride-th pixel of the image in each
+// direction, rather than every pixel. A stride below 1 is treated as 1.
+func WithGridSampling(stride int) Option {
+	return func(cfg *config) {
+		cfg.sampler = gridSampler{stride: maxInt(stride, 1)}
+	}
+}
+
+// WithRandomSampling considers only n distinct pixels of the image, chosen
+// uniformly at random, which bounds the cost of extraction regardless of the
+// size of the image. Unlike resizing the image first, sampling only ever
+// yields colors that are actually present in the image.
+func WithRandomSampling(n int) Option {
+	return func(cfg *config) {
+		cfg.sampler = randomSampler{n: maxInt(n, 1)}
+	}
+}
+
+// WithTileSampling divides the image into a grid of tiles*tiles equally sized
+// tiles and considers perTile distinct pixels chosen at random from each one,
+// so that every part of the image is represented in the sample.
+func WithTileSampling(tiles, perTile int) Option {
+	return func(cfg *config) {
+		cfg.sampler = tileSampler{tiles: maxInt(tiles, 1), perTile: maxInt(perTile, 1)}
+	}
+}
+
+// WithRegion restricts extraction to the pixels of the image that fall within
+// r, e.g. the bounding box of a product in a photo.
+func WithRegion(r image.Rectangle) Option {
+	return func(cfg *config) {
+		cfg.region = &r
+	}
+}
+
+// WithMask weights each pixel of the image by the alpha value of the mask at
+// the same coordinates, e.g. an *image.Alpha segmentation mask. Pixels where
+// the mask is fully transparent are ignored.
+func WithMask(mask image.Image) Option {
+	return func(cfg *config) {
+		cfg.mask = mask
+	}
+}
+
+// WithWeightFunc weights each pixel of the image by the value returned by fn.
+// Weights from multiple weighting options, including WithMask, are multiplied
+// together, and flow through clustering into the weight of each Entry.
+func WithWeightFunc(fn WeightFunc) Option {
+	return func(cfg *config) {
+		cfg.weighters = append(cfg.weighters, func(image.Image, image.Rectangle) WeightFunc {
+			return fn
+		})
+	}
+}
+
+// WithCenterWeighting weights pixels with a Gaussian bias towards the center
+// of the image (or region), so that the subject of a photo outweighs large,
+// dull borders. The standard deviation of the Gaussian is sigma times the
+// width and height of the image; 0.25 is a reasonable starting point. Extract
+// fails if sigma is not positive.
+func WithCenterWeighting(sigma float64) Option {
+	return func(cfg *config) {
+		if !(sigma > 0) {
+			if cfg.err == nil {
+				cfg.err = fmt.Errorf("invalid center weighting sigma %v: must be positive", sigma)
+			}
+			return
+		}
+		cfg.weighters = append(cfg.weighters, centerWeighter(sigma))
+	}
+}
+
+// WithSaliencyWeighting weights pixels by an estimate of their visual
+// saliency, based on the local contrast around each pixel, so that detailed
+// areas outweigh flat ones.
+func WithSaliencyWeighting() Option {
+	return func(cfg *config) {
+		cfg.weighters = append(cfg.weighters, saliencyWeighter)
+	}
+}
+
+// WithBlendSpace sets the color space in which colors are averaged, both to
+// find the center of each cluster and to blend merged entries together. The
+// default is BlendHCL.
+func WithBlendSpace(space BlendSpace) Option {
+	return func(cfg *config) {
+		cfg.blendSpace = space
+	}
+}
+
+// WithMergeThreshold merges palette entries whose colors differ by less than
+// the given CIEDE2000 ΔE once clustering is complete. See Palette.Merge.
+func WithMergeThreshold(deltaE float64) Option {
+	return func(cfg *config) {
+		cfg.mergeThreshold = deltaE
+	}
+}
+
+// WithMinWeight drops palette entries whose weight is below minWeight once
+// clustering is complete, renormalizing the remaining weights. The most
+// dominant entry is always kept. See Palette.Prune.
+func WithMinWeight(minWeight float64) Option {
+	return func(cfg *config) {
+		cfg.minWeight = minWeight
+	}
+}
+
+// WithExclusion drops every pixel within the given CIEDE2000 ΔE tolerance of c
+// before clustering, e.g. to ignore a known background color. It may be
+// passed more than once to exclude several colors. The share of excluded
+// pixels is reported by Palette.Excluded.
+func WithExclusion(c color.Color, tolerance float64) Option {
+	return func(cfg *config) {
+		cfg.excludedColors = append(cfg.excludedColors, exclusion{color: c, tolerance: tolerance})
+	}
+}
+
+// WithBackgroundExclusion detects the background color of an image from its
+// border, as DetectBackground does, and drops every pixel within the given
+// CIEDE2000 ΔE tolerance of it before clustering.
+func WithBackgroundExclusion(tolerance float64) Option {
+	return func(cfg *config) {
+		cfg.excludeBackground = true
+		cfg.backgroundTolerance = tolerance
+	}
+}
@@ -0,0 +1,209 @@
+package palettor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An Order determines the order in which the entries of a Palette are
+// returned by EntriesBy.
+type Order int
+
+const (
+	// ByWeight orders entries from least to most dominant.
+	ByWeight Order = iota
+	// ByWeightDescending orders entries from most to least dominant.
+	ByWeightDescending
+	// ByHue orders entries by hue angle, starting from red. Greys and other
+	// nearly achromatic entries, whose hue is just noise, come last, from
+	// darkest to lightest.
+	ByHue
+	// ByLightness orders entries from darkest to lightest.
+	ByLightness
+	// ByChroma orders entries from least to most colorful.
+	ByChroma
+	// BySmoothPath orders entries so that adjacent colors are perceptually
+	// similar, approximately minimizing the total ΔE between neighbors. The
+	// path starts at the most dominant entry.
+	BySmoothPath
+)
+
+var orderNames = []string{
+	ByWeight:           "weight",
+	ByWeightDescending: "weight-desc",
+	ByHue:              "hue",
+	ByLightness:        "lightness",
+	ByChroma:           "chroma",
+	BySmoothPath:       "smooth",
+}
+
+// String returns the name of an Order, as accepted by ParseOrder.
+func (o Order) String() string {
+	return enumString(orderNames, "Order", int(o))
+}
+
+// ParseOrder returns the Order with the given name.
+func ParseOrder(name string) (Order, error) {
+	i, err := parseEnum(orderNames, "palette order", name)
+	return Order(i), err
+}
+
+// enumString returns the name of the enum value v in names, which is indexed
+// by value, or kind(v) if v has no name, e.g. "Order(9)".
+func enumString(names []string, kind string, v int) string {
+	if v >= 0 && v < len(names) && names[v] != "" {
+		return names[v]
+	}
+	return fmt.Sprintf("%s(%d)", kind, v)
+}
+
+// parseEnum returns the value of the enum whose name in names is the given
+// one. The description of the enum is used in the error for unknown names.
+func parseEnum(names []string, description, name string) (int, error) {
+	for v, candidate := range names {
+		if candidate != "" && candidate == name {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s: %q", description, name)
+}
+
+// huelessChroma is the HCL chroma below which ByHue considers colors to have no
+// meaningful hue.
+const huelessChroma = 0.05
+
+// sortEntries sorts entries in place according to the given order.
+func sortEntries(entries []Entry, order Order) {
+	s := entrySorter{
+		entries: entries,
+		colors:  make([]hcl, len(entries)),
+	}
+	for i, entry := range entries {
+		s.colors[i] = entryHCL(entry)
+	}
+
+	switch order {
+	case ByWeightDescending:
+		s.key = func(e Entry, _ hcl) float64 { return -e.Weight }
+	case ByHue:
+		s.key = func(_ Entry, c hcl) float64 {
+			if c.c < huelessChroma {
+				// Past every hue angle, which is below 360
+				return 360 + c.l
+			}
+			return c.h
+		}
+	case ByLightness:
+		s.key = func(_ Entry, c hcl) float64 { return c.l }
+	case ByChroma:
+		s.key = func(_ Entry, c hcl) float64 { return c.c }
+	case BySmoothPath:
+		s.key = func(e Entry, _ hcl) float64 { return -e.Weight }
+		sort.Sort(s)
+		smoothPath(entries, s.colors)
+		return
+	default:
+		s.key = func(e Entry, _ hcl) float64 { return e.Weight }
+	}
+	sort.Sort(s)
+}
+
+// entrySorter implements sort.Interface, ordering entries by an arbitrary key
+// and falling back on weight and then on the raw color value to break ties.
+type entrySorter struct {
+	entries []Entry
+	colors  []hcl
+	key     func(Entry, hcl) float64
+}
+
+func (s entrySorter) Len() int { return len(s.entries) }
+
+func (s entrySorter) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.colors[i], s.colors[j] = s.colors[j], s.colors[i]
+}
+
+func (s entrySorter) Less(i, j int) bool {
+	a, b := s.entries[i], s.entries[j]
+	if ka, kb := s.key(a, s.colors[i]), s.key(b, s.colors[j]); ka != kb {
+		return ka < kb
+	}
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	return asKey(a.Color).less(asKey(b.Color))
+}
+
+func (k rgbaKey) less(other rgbaKey) bool {
+	for i := range k {
+		if k[i] != other[i] {
+			return k[i] < other[i]
+		}
+	}
+	return false
+}
+
+// smoothPath reorders entries, which must already be in a deterministic
+// order, into a short path through color space: a greedy nearest-neighbor
+// tour from the first entry, refined with 2-opt moves until no reversal of a
+// sub-path shortens the total ΔE between neighbors.
+func smoothPath(entries []Entry, colors []hcl) {
+	n := len(entries)
+	if n < 3 {
+		return
+	}
+
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+		for j := range dist[i] {
+			dist[i][j] = colors[i].deltaE(colors[j])
+		}
+	}
+
+	path := make([]int, 0, n)
+	visited := make([]bool, n)
+	path = append(path, 0)
+	visited[0] = true
+	for len(path) < n {
+		last := path[len(path)-1]
+		next := -1
+		for j := 0; j < n; j++ {
+			if !visited[j] && (next == -1 || dist[last][j] < dist[last][next]) {
+				next = j
+			}
+		}
+		path = append(path, next)
+		visited[next] = true
+	}
+
+	// 2-opt on an open path: reversing path[i:j+1] replaces the edges
+	// (i-1, i) and (j, j+1) with (i-1, j) and (i, j+1). The first entry is
+	// kept fixed so the path always starts at the most dominant color.
+	const epsilon = 1e-9
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				before := dist[path[i-1]][path[i]]
+				after := dist[path[i-1]][path[j]]
+				if j+1 < n {
+					before += dist[path[j]][path[j+1]]
+					after += dist[path[i]][path[j+1]]
+				}
+				if after < before-epsilon {
+					for a, b := i, j; a < b; a, b = a+1, b-1 {
+						path[a], path[b] = path[b], path[a]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+
+	sorted := make([]Entry, n)
+	for i, index := range path {
+		sorted[i] = entries[index]
+	}
+	copy(entries, sorted)
+}
@@ -0,0 +1,128 @@
+package palettor
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntriesBy(t *testing.T) {
+	palette := &Palette{}
+	palette.add(black, 0.1)
+	palette.add(white, 0.2)
+	palette.add(red, 0.3)
+	palette.add(blue, 0.4)
+
+	colorsOf := func(entries []Entry) []color.Color {
+		var colors []color.Color
+		for _, entry := range entries {
+			colors = append(colors, entry.Color)
+		}
+		return colors
+	}
+
+	assert.Equal(t, []color.Color{black, white, red, blue}, colorsOf(palette.EntriesBy(ByWeight)))
+	assert.Equal(t, []color.Color{blue, red, white, black}, colorsOf(palette.EntriesBy(ByWeightDescending)))
+	assert.Equal(t, []color.Color{black, blue, red, white}, colorsOf(palette.EntriesBy(ByLightness)))
+	assert.Equal(t, palette.Entries(), palette.EntriesBy(ByWeight))
+	assert.Equal(t, colorsOf(palette.Entries()), palette.Colors())
+
+	byChroma := colorsOf(palette.EntriesBy(ByChroma))
+	assert.Equal(t, []color.Color{red, blue}, byChroma[2:], "achromatic colors should sort first by chroma")
+
+	byHue := colorsOf(palette.EntriesBy(ByHue))
+	assert.Equal(t, []color.Color{red, blue, black, white}, byHue, "achromatic colors should sort last by lightness")
+
+	// Nearly achromatic colors have noisy hues, which must not scatter them
+	// among the others
+	greenishGrey := forceHCL(color.RGBA{60, 61, 60, 255})
+	reddishGrey := forceHCL(color.RGBA{200, 198, 198, 255})
+	palette.add(greenishGrey, 0.5)
+	palette.add(reddishGrey, 0.5)
+	byHue = colorsOf(palette.EntriesBy(ByHue))
+	assert.Equal(t, []color.Color{red, blue, black, greenishGrey, reddishGrey, white}, byHue)
+}
+
+func TestEntriesByTies(t *testing.T) {
+	palette := &Palette{}
+	for _, c := range []hcl{black, white, red, green, blue} {
+		palette.add(c, 0.2)
+	}
+
+	expected := palette.EntriesBy(ByWeight)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, expected, palette.EntriesBy(ByWeight), "ties should be broken deterministically")
+	}
+}
+
+func TestEntriesBySmoothPath(t *testing.T) {
+	// A lightness gradient, added out of order, with the darkest step being
+	// the most dominant.
+	steps := []uint8{0, 64, 128, 192, 255}
+	shuffled := []int{3, 0, 4, 1, 2}
+	palette := &Palette{}
+	for _, i := range shuffled {
+		v := steps[i]
+		weight := 0.1
+		if i == 0 {
+			weight = 0.6
+		}
+		palette.add(forceHCL(color.RGBA{v, v, v, 255}), weight)
+	}
+
+	entries := palette.EntriesBy(BySmoothPath)
+	assert.Len(t, entries, len(steps))
+	for i, entry := range entries {
+		r, _, _, _ := entry.Color.RGBA()
+		assert.Equal(t, steps[i], uint8(r>>8), "smooth path should walk the gradient from the dominant end")
+	}
+
+	assert.Equal(t, entries, palette.EntriesBy(BySmoothPath))
+}
+
+func TestEnumNames(t *testing.T) {
+	for kind, tc := range map[string]struct {
+		values  []fmt.Stringer
+		parse   func(string) (fmt.Stringer, error)
+		unknown fmt.Stringer
+	}{
+		"Order": {
+			[]fmt.Stringer{ByWeight, ByWeightDescending, ByHue, ByLightness, ByChroma, BySmoothPath},
+			func(name string) (fmt.Stringer, error) { return ParseOrder(name) },
+			Order(99),
+		},
+		"BlendSpace": {
+			[]fmt.Stringer{BlendHCL, BlendLinearRGB, BlendOklab},
+			func(name string) (fmt.Stringer, error) { return ParseBlendSpace(name) },
+			BlendSpace(99),
+		},
+		"Naming": {
+			[]fmt.Stringer{NameByIndex, NameByRank, NameByColor},
+			func(name string) (fmt.Stringer, error) { return ParseNaming(name) },
+			Naming(99),
+		},
+		"Role": {
+			[]fmt.Stringer{Vibrant, LightVibrant, DarkVibrant, Muted, LightMuted, DarkMuted},
+			func(name string) (fmt.Stringer, error) { return ParseRole(name) },
+			Role(99),
+		},
+		"ScaleSpace": {
+			[]fmt.Stringer{ScaleHCL, ScaleOklch},
+			func(name string) (fmt.Stringer, error) { return ParseScaleSpace(name) },
+			ScaleSpace(99),
+		},
+	} {
+		for _, v := range tc.values {
+			parsed, err := tc.parse(v.String())
+			assert.NoError(t, err, kind)
+			assert.Equal(t, v, parsed, kind)
+		}
+		_, err := tc.parse("bogus")
+		assert.Error(t, err, kind)
+		_, err = tc.parse("")
+		assert.Error(t, err, kind)
+		assert.Equal(t, kind+"(99)", tc.unknown.String())
+	}
+}
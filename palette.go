@@ -2,9 +2,9 @@ package palettor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/color"
-	"sort"
 
 	"github.com/lucasb-eyer/go-colorful"
 )
@@ -21,65 +21,347 @@ func asKey(c color.Color) rgbaKey {
 // used as an approximation for that color's relative dominance in an image.
 type Palette struct {
 	entries    map[rgbaKey]Entry
+	k          int
 	converged  bool
 	iterations int
+	excluded   float64
+	blendSpace BlendSpace
+	profile    *Profile
+	dictionary *Dictionary
 }
 
+// derive returns an empty Palette with the same metadata as p, to hold entries
+// derived from those of p.
+func (p *Palette) derive() *Palette {
+	return &Palette{
+		k:          p.k,
+		converged:  p.converged,
+		iterations: p.iterations,
+		excluded:   p.excluded,
+		blendSpace: p.blendSpace,
+		profile:    p.profile,
+		dictionary: p.dictionary,
+	}
+}
+
+// add adds a color to p, named after the dictionary of p, replacing any entry
+// of the same color.
 func (p *Palette) add(c color.Color, weight float64) {
 	if p.entries == nil {
 		p.entries = make(map[rgbaKey]Entry)
 	}
-	p.entries[asKey(c)] = Entry{Color: c, Weight: weight}
+	entry := Entry{Color: c, Weight: weight}
+	if p.dictionary != nil {
+		entry.Name, _ = p.dictionary.Nearest(c)
+	}
+	p.entries[asKey(c)] = entry
+}
+
+// paletteOf builds a Palette from decoded entries. The weights of entries that
+// share a color are summed. Entries without a weight get an equal share of the
+// palette: if none of the entries carry a weight, all colors are weighted
+// equally, and otherwise each unweighted entry weighs 1/n of n entries, and
+// the others share the rest in proportion to their weights. Entries keep their
+// names, if any, and the others are named after DictionaryCSS.
+func paletteOf(entries []Entry) *Palette {
+	p := &Palette{dictionary: DictionaryCSS}
+	var total float64
+	unweighted := 0
+	for _, e := range entries {
+		total += e.Weight
+		if e.Weight == 0 {
+			unweighted++
+		}
+	}
+	share := 1 / float64(len(entries))
+	for _, e := range entries {
+		weight := e.Weight
+		switch {
+		case weight == 0:
+			weight = share
+		case unweighted > 0:
+			weight *= float64(len(entries)-unweighted) * share / total
+		}
+		p.add(e.Color, p.Weight(e.Color)+weight)
+		if e.Name != "" {
+			entry := p.entries[asKey(e.Color)]
+			entry.Name = e.Name
+			p.entries[asKey(e.Color)] = entry
+		}
+	}
+	return p
 }
 
 // Entry is a color and its weight in a Palette
 type Entry struct {
 	Color  color.Color `json:"color"`
 	Weight float64     `json:"weight"`
+	// Name is the name of the nearest color in the Dictionary the palette was
+	// named after, e.g. "steelblue", or empty if it was not named.
+	Name string `json:"name,omitempty"`
+}
+
+// entryHCL returns the HCL representation of an entry's color. Palette colors
+// are always opaque, so the conversion cannot fail in practice.
+func entryHCL(e Entry) hcl {
+	c, _ := toHCL(e.Color)
+	return c
+}
+
+// RGBA64 returns the color of an entry at 16-bit precision.
+func (e Entry) RGBA64() color.RGBA64 {
+	return color.RGBA64Model.Convert(e.Color).(color.RGBA64)
+}
+
+// RGB returns the color of an entry as sRGB values in the [0, 1] interval. For
+// palettes produced by Extract, the values carry the full floating point
+// precision of the clustering algorithm.
+func (e Entry) RGB() (r, g, b float64) {
+	if c, ok := e.Color.(hcl); ok {
+		clamped := c.colorful().Clamped()
+		return clamped.R, clamped.G, clamped.B
+	}
+	c, _ := colorful.MakeColor(e.Color)
+	return c.R, c.G, c.B
+}
+
+// Hex returns the color of an entry as a CSS hex string, e.g. "#2c487c".
+func (e Entry) Hex() string {
+	return e.colorful().Hex()
+}
+
+// colorful returns the color of an entry at full precision, clamped to the
+// sRGB gamut.
+func (e Entry) colorful() colorful.Color {
+	r, g, b := e.RGB()
+	return colorful.Color{R: r, G: g, B: b}
+}
+
+// floatRGB is the JSON representation of Entry.RGB.
+type floatRGB struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
 }
 
 // MarshalJSON turns e into a more usefully readable JSON structure, with a hex
-// value and RGB values in the 0-255 interval.
+// value, RGB values in the 0-255 interval, and higher precision RGB values in
+// the 0-65535 and 0-1 intervals.
 func (e Entry) MarshalJSON() ([]byte, error) {
 	type Alias Entry
-	// Bodge: convert to colorful.Color for easier representation.
-	c, ok := colorful.MakeColor(e.Color)
-	if !ok {
+	if _, ok := colorful.MakeColor(e.Color); !ok {
 		return nil, fmt.Errorf("colorful can't handle color: %+v", e.Color)
 	}
-	r, g, b := c.RGB255()
+	r, g, b := e.colorful().RGB255()
+	var f floatRGB
+	f.R, f.G, f.B = e.RGB()
 	return json.Marshal(&struct {
-		Color color.RGBA `json:"color"`
-		Hex   string     `json:"hex"`
+		Color  color.RGBA   `json:"color"`
+		Hex    string       `json:"hex"`
+		RGBA64 color.RGBA64 `json:"rgba64"`
+		Float  floatRGB     `json:"float"`
 		Alias
 	}{
-		Color: color.RGBA{r, g, b, 255},
-		Hex:   c.Hex(),
-		Alias: (Alias)(e),
+		Color:  color.RGBA{r, g, b, 255},
+		Hex:    e.Hex(),
+		RGBA64: e.RGBA64(),
+		Float:  f,
+		Alias:  (Alias)(e),
 	})
 }
 
-// Entries returns a slice of Entry structs, sorted by weight
+// UnmarshalJSON reads an entry written by MarshalJSON, restoring its color from
+// the most precise representation present: the float, rgba64, color or hex
+// value, in that order.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Color  *color.RGBA   `json:"color"`
+		Hex    string        `json:"hex"`
+		RGBA64 *color.RGBA64 `json:"rgba64"`
+		Float  *floatRGB     `json:"float"`
+		Weight float64       `json:"weight"`
+		Name   string        `json:"name"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var c colorful.Color
+	switch {
+	case fields.Float != nil:
+		c = colorful.Color{R: fields.Float.R, G: fields.Float.G, B: fields.Float.B}
+	case fields.RGBA64 != nil:
+		c, _ = colorful.MakeColor(*fields.RGBA64)
+	case fields.Color != nil:
+		c, _ = colorful.MakeColor(*fields.Color)
+	case fields.Hex != "":
+		var err error
+		if c, err = colorful.Hex(fields.Hex); err != nil {
+			return fmt.Errorf("invalid entry color: %w", err)
+		}
+	default:
+		return errors.New("entry has no color")
+	}
+	*e = Entry{Color: fromColorful(c), Weight: fields.Weight, Name: fields.Name}
+	return nil
+}
+
+// paletteSchemaVersion is the version of the JSON representation of a
+// Palette, to be incremented on incompatible changes.
+const paletteSchemaVersion = 1
+
+// paletteJSON is the JSON representation of a Palette.
+type paletteJSON struct {
+	Version       int    `json:"version"`
+	Algorithm     string `json:"algorithm"`
+	K             int    `json:"k"`
+	Iterations    int    `json:"iterations"`
+	Converged     bool   `json:"converged"`
+	ColorSpace    string `json:"color_space"`
+	BlendSpace    string `json:"blend_space"`
+	SourceProfile string `json:"source_profile"`
+	// SourceProfileData describes source profiles other than the predefined
+	// ones in full, since their name alone cannot restore them.
+	SourceProfileData *Profile `json:"source_profile_data,omitempty"`
+	Dictionary        string   `json:"dictionary,omitempty"`
+	Excluded          float64  `json:"excluded"`
+	Entries           []Entry  `json:"entries"`
+}
+
+// MarshalJSON encodes p, along with the metadata describing how it was
+// extracted, in a versioned schema that UnmarshalJSON reads back.
+func (p *Palette) MarshalJSON() ([]byte, error) {
+	var dictionary string
+	if p.dictionary != nil {
+		dictionary = p.dictionary.Name()
+	}
+	var profileData *Profile
+	if knownProfile(p.SourceProfile().Name()) != p.SourceProfile() {
+		profileData = p.profile
+	}
+	return json.Marshal(&paletteJSON{
+		Version:           paletteSchemaVersion,
+		Algorithm:         "kmeans",
+		K:                 p.k,
+		Iterations:        p.iterations,
+		Converged:         p.converged,
+		ColorSpace:        ProfileSRGB.Name(),
+		BlendSpace:        p.blendSpace.String(),
+		SourceProfile:     p.SourceProfile().Name(),
+		SourceProfileData: profileData,
+		Dictionary:        dictionary,
+		Excluded:          p.excluded,
+		Entries:           p.Entries(),
+	})
+}
+
+// UnmarshalJSON restores a Palette encoded by MarshalJSON. Dictionaries are
+// only recorded by name, so the one loss is that of a Dictionary other than
+// the predefined ones, like DictionaryCSS: entry names are restored as
+// recorded, but the restored palette has no Dictionary, and entries derived
+// from it, e.g. by Merge, are unnamed.
+func (p *Palette) UnmarshalJSON(data []byte) error {
+	var fields paletteJSON
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if fields.Version != paletteSchemaVersion {
+		return fmt.Errorf("unsupported palette schema version %d", fields.Version)
+	}
+	if fields.Algorithm != "kmeans" {
+		return fmt.Errorf("unsupported palette algorithm %q", fields.Algorithm)
+	}
+	if fields.ColorSpace != ProfileSRGB.Name() {
+		return fmt.Errorf("unsupported palette color space %q", fields.ColorSpace)
+	}
+	blendSpace, err := ParseBlendSpace(fields.BlendSpace)
+	if err != nil {
+		return err
+	}
+	profile := knownProfile(fields.SourceProfile)
+	if data := fields.SourceProfileData; data != nil {
+		profile = data
+		for _, known := range knownProfiles {
+			if data.matches(known) {
+				profile = known
+			}
+		}
+	}
+	if profile == nil {
+		return fmt.Errorf("cannot restore source profile %q", fields.SourceProfile)
+	}
+
+	var dictionary *Dictionary
+	for _, known := range knownDictionaries {
+		if known.Name() == fields.Dictionary {
+			dictionary = known
+		}
+	}
+
+	*p = Palette{
+		k:          fields.K,
+		converged:  fields.Converged,
+		iterations: fields.Iterations,
+		excluded:   fields.Excluded,
+		blendSpace: blendSpace,
+		profile:    profile,
+		dictionary: dictionary,
+		entries:    make(map[rgbaKey]Entry),
+	}
+	for _, e := range fields.Entries {
+		p.entries[asKey(e.Color)] = e
+	}
+	return nil
+}
+
+// Entries returns a slice of Entry structs, sorted by weight from least to
+// most dominant.
 func (p *Palette) Entries() []Entry {
+	return p.EntriesBy(ByWeight)
+}
+
+// EntriesBy returns a slice of Entry structs in the given order. Ties are
+// broken deterministically, so the same palette always yields the same order.
+func (p *Palette) EntriesBy(order Order) []Entry {
 	entries := make([]Entry, p.Count())
 	i := 0
 	for _, entry := range p.entries {
 		entries[i] = entry
 		i++
 	}
-	sort.Sort(byWeight(entries))
+	sortEntries(entries, order)
 	return entries
 }
 
-// Colors returns a slice of the colors that comprise a Palette.
+// Colors returns a slice of the colors that comprise a Palette, in the same
+// order as Entries.
 func (p *Palette) Colors() []color.Color {
-	var colors []color.Color
-	for _, entry := range p.entries {
-		colors = append(colors, entry.Color)
+	entries := p.Entries()
+	colors := make([]color.Color, len(entries))
+	for i, entry := range entries {
+		colors[i] = entry.Color
 	}
 	return colors
 }
 
+// Named returns a copy of p whose entries are named after the nearest colors
+// in the given Dictionary, or unnamed if it is nil. The receiver is not
+// modified.
+func (p *Palette) Named(dictionary *Dictionary) *Palette {
+	result := p.derive()
+	result.dictionary = dictionary
+	for _, e := range p.entries {
+		result.add(e.Color, e.Weight)
+	}
+	return result
+}
+
+// Dictionary returns the Dictionary after which the entries of a Palette are
+// named, or nil if they are not named.
+func (p *Palette) Dictionary() *Dictionary {
+	return p.dictionary
+}
+
 // Converged returns a bool indicating whether a stable set of dominant
 // colors was found before the maximum number of iterations was reached.
 func (p *Palette) Converged() bool {
@@ -91,6 +373,35 @@ func (p *Palette) Count() int {
 	return len(p.entries)
 }
 
+// Excluded returns the share of the image's pixels, as a float in the range
+// [0, 1], that were excluded from clustering by WithExclusion or
+// WithBackgroundExclusion.
+func (p *Palette) Excluded() float64 {
+	return p.excluded
+}
+
+// BlendSpace returns the color space in which the colors of a Palette were
+// averaged.
+func (p *Palette) BlendSpace() BlendSpace {
+	return p.blendSpace
+}
+
+// SourceProfile returns the color profile of the image the Palette was
+// extracted from. The colors of the Palette itself are always sRGB; use
+// Profile.Encode to express them in the source profile.
+func (p *Palette) SourceProfile() *Profile {
+	if p.profile == nil {
+		return ProfileSRGB
+	}
+	return p.profile
+}
+
+// K returns the number of clusters the colors of a Palette were grouped into.
+// The Palette may have fewer entries, if any were merged or pruned.
+func (p *Palette) K() int {
+	return p.k
+}
+
 // Iterations returns the number of iterations required to extract the colors
 // of a Palette.
 func (p *Palette) Iterations() int {
@@ -106,10 +417,3 @@ func (p *Palette) Weight(c color.Color) float64 {
 	}
 	return entry.Weight
 }
-
-// implement sort.Interface
-type byWeight []Entry
-
-func (a byWeight) Len() int           { return len(a) }
-func (a byWeight) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byWeight) Less(i, j int) bool { return a[i].Weight < a[j].Weight }
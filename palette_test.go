@@ -1,6 +1,10 @@
 package palettor
 
 import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,8 +33,170 @@ func TestPalette(t *testing.T) {
 
 	// ensure entries are sorted by weight
 	expectedEntries := []Entry{
-		{white, 0.25},
-		{black, 0.75},
+		{Color: white, Weight: 0.25},
+		{Color: black, Weight: 0.75},
 	}
 	assert.Equal(t, expectedEntries, palette.Entries())
 }
+
+func TestEntryPrecision(t *testing.T) {
+	// A 16-bit color that can't be represented exactly in 8 bits
+	deep := color.RGBA64{0x1234, 0x89ab, 0xcdef, 0xffff}
+	img := image.NewRGBA64(image.Rect(0, 0, 2, 2))
+	draw.Draw(img, img.Bounds(), &image.Uniform{deep}, image.Point{}, draw.Src)
+
+	palette, err := Extract(1, 10, img)
+	assert.NoError(t, err)
+	entry := palette.Entries()[0]
+	assert.Equal(t, deep, entry.RGBA64())
+
+	r, g, b := entry.RGB()
+	assert.InDelta(t, float64(0x1234)/0xffff, r, 1e-9)
+	assert.InDelta(t, float64(0x89ab)/0xffff, g, 1e-9)
+	assert.InDelta(t, float64(0xcdef)/0xffff, b, 1e-9)
+
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+	var decoded struct {
+		Color  color.RGBA
+		Hex    string
+		RGBA64 color.RGBA64
+		Float  struct{ R, G, B float64 }
+	}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, color.RGBA{0x12, 0x89, 0xcd, 0xff}, decoded.Color)
+	assert.Equal(t, "#1289cd", decoded.Hex)
+	assert.Equal(t, deep, decoded.RGBA64)
+	assert.InDelta(t, r, decoded.Float.R, 1e-9)
+}
+
+func TestEntryUnmarshalJSON(t *testing.T) {
+	entry := Entry{Color: forceHCL(color.RGBA64{0x1234, 0x89ab, 0xcdef, 0xffff}), Weight: 0.25}
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+
+	var decoded Entry
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, entry.RGBA64(), decoded.RGBA64())
+	assert.Equal(t, entry.Weight, decoded.Weight)
+	r, g, b := entry.RGB()
+	decodedR, decodedG, decodedB := decoded.RGB()
+	assert.InDelta(t, r, decodedR, 1e-12)
+	assert.InDelta(t, g, decodedG, 1e-12)
+	assert.InDelta(t, b, decodedB, 1e-12)
+
+	// Less precise representations, as written by older versions
+	for input, expected := range map[string]color.RGBA{
+		`{"color": {"R": 70, "G": 134, "B": 154, "A": 255}, "weight": 0.5}`: {70, 134, 154, 255},
+		`{"hex": "#46869a", "weight": 0.5}`:                                 {70, 134, 154, 255},
+	} {
+		assert.NoError(t, json.Unmarshal([]byte(input), &decoded))
+		assert.Equal(t, expected, color.RGBAModel.Convert(decoded.Color))
+		assert.Equal(t, 0.5, decoded.Weight)
+	}
+
+	assert.Error(t, json.Unmarshal([]byte(`{"weight": 0.5}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"hex": "blue"}`), &decoded))
+}
+
+func TestPaletteJSONRoundTrip(t *testing.T) {
+	palette := &Palette{
+		k:          3,
+		converged:  true,
+		iterations: 7,
+		excluded:   0.1,
+		blendSpace: BlendOklab,
+		profile:    ProfileDisplayP3,
+	}
+	palette.add(forceHCL(color.RGBA64{0x1234, 0x89ab, 0xcdef, 0xffff}), 0.25)
+	palette.add(forceHCL(color.RGBA{200, 0, 0, 255}), 0.75)
+
+	data, err := json.Marshal(palette)
+	assert.NoError(t, err)
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, 1.0, fields["version"])
+	assert.Equal(t, "kmeans", fields["algorithm"])
+	assert.Equal(t, "sRGB", fields["color_space"])
+	assert.Equal(t, "oklab", fields["blend_space"])
+	assert.Equal(t, "Display P3", fields["source_profile"])
+
+	var decoded Palette
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, 3, decoded.K())
+	assert.Equal(t, 2, decoded.Count())
+	assert.True(t, decoded.Converged())
+	assert.Equal(t, 7, decoded.Iterations())
+	assert.Equal(t, 0.1, decoded.Excluded())
+	assert.Equal(t, BlendOklab, decoded.BlendSpace())
+	assert.Equal(t, ProfileDisplayP3, decoded.SourceProfile())
+	for i, entry := range decoded.Entries() {
+		assert.Equal(t, palette.Entries()[i].RGBA64(), entry.RGBA64())
+		assert.Equal(t, palette.Entries()[i].Weight, entry.Weight)
+	}
+}
+
+func TestPaletteJSONCustomProfile(t *testing.T) {
+	// Profiles read from images are restored from their matrix and curves
+	rec2020 := newProfile("Rec. 2020", [3][2]float64{{0.708, 0.292}, {0.170, 0.797}, {0.131, 0.046}}, nil)
+	for _, curve := range [][]byte{gammaCurveTag(2.2), tableCurveTag(2.2, 256)} {
+		profile, err := ParseProfile(buildICC(rec2020, "Some Camera RGB", curve))
+		assert.NoError(t, err)
+		palette := &Palette{profile: profile}
+		palette.add(forceHCL(color.RGBA{200, 0, 0, 255}), 1)
+
+		data, err := json.Marshal(palette)
+		assert.NoError(t, err)
+		var decoded Palette
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		restored := decoded.SourceProfile()
+		assert.Equal(t, "Some Camera RGB", restored.Name())
+		assert.True(t, restored.matches(profile))
+		c := palette.Entries()[0].Color
+		assert.Equal(t, profile.Encode(c), restored.Encode(c))
+	}
+
+	// Predefined profiles are recorded by name only
+	data, err := json.Marshal(&Palette{profile: ProfileDisplayP3})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "source_profile_data")
+}
+
+func TestPaletteUnmarshalJSONInvalid(t *testing.T) {
+	valid := map[string]interface{}{
+		"version":        1,
+		"algorithm":      "kmeans",
+		"color_space":    "sRGB",
+		"blend_space":    "hcl",
+		"source_profile": "sRGB",
+		"entries":        []interface{}{},
+	}
+	for field, value := range map[string]interface{}{
+		"version":        2,
+		"algorithm":      "median-cut",
+		"color_space":    "Display P3",
+		"blend_space":    "cmyk",
+		"source_profile": "Some Camera RGB",
+		"source_profile_data": map[string]interface{}{
+			"name":   "Some Camera RGB",
+			"to_xyz": [3][3]float64{},
+			"curves": []interface{}{},
+		},
+		"entries": []interface{}{map[string]interface{}{"weight": 1}},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range valid {
+			invalid[k] = v
+		}
+		invalid[field] = value
+		data, err := json.Marshal(invalid)
+		assert.NoError(t, err)
+		var p Palette
+		assert.Error(t, json.Unmarshal(data, &p), "invalid %s", field)
+	}
+
+	data, err := json.Marshal(valid)
+	assert.NoError(t, err)
+	var p Palette
+	assert.NoError(t, json.Unmarshal(data, &p))
+}
@@ -1,5 +1,14 @@
 // Package palettor provides a way to extract the color palette from an image
 // using k-means clustering.
+//
+// # Swatch formats
+//
+// Palettes can be exchanged with design tools as GIMP palettes (.gpl), Adobe
+// Swatch Exchange files (.ase) and Photoshop color swatches (.aco). None of
+// these formats has a place for the weight of a color, so EncodeGPL, EncodeASE
+// and EncodeACO record it in a comment or in the name of the swatch, and the
+// matching decoders restore it. Files from other sources carry no weights, and
+// their colors are weighted equally.
 package palettor
 
 import (
@@ -10,27 +19,117 @@ import (
 // Extract finds the k most dominant colors in the given image using the
 // "standard" k-means clustering algorithm. It returns a Palette, after running
 // the algorithm up to maxIterations times.
-func Extract(k, maxIterations int, img image.Image) (*Palette, error) {
-	imgColors, err := getColors(img)
+//
+// The extraction can be customized by passing any number of Options.
+func Extract(k, maxIterations int, img image.Image, opts ...Option) (*Palette, error) {
+	cfg := newConfig(opts)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	imgColors, weights, err := getColors(img, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("error extracting colors from image: %w", err)
 	}
-	return clusterColors(k, maxIterations, imgColors)
+
+	exclusions, err := cfg.exclusions(img)
+	if err != nil {
+		return nil, err
+	}
+	imgWeight := totalWeight(weights, len(imgColors))
+	colors, weights := exclude(imgColors, weights, exclusions)
+
+	palette, err := clusterColors(k, maxIterations, colors, weights, cfg.blendSpace)
+	if err != nil {
+		return nil, err
+	}
+	palette.excluded = 1 - totalWeight(weights, len(colors))/imgWeight
+	palette.profile = cfg.profile
+	palette = palette.Named(cfg.dictionary)
+	if cfg.mergeThreshold > 0 {
+		palette = palette.Merge(cfg.mergeThreshold)
+	}
+	if cfg.minWeight > 0 {
+		palette = palette.Prune(cfg.minWeight)
+	}
+	return palette, nil
 }
 
-func getColors(img image.Image) ([]hcl, error) {
+// getColors returns the colors of the pixels in img that are selected by cfg,
+// along with the weight of each pixel. The weights are nil if every pixel is
+// weighted equally.
+func getColors(img image.Image, cfg *config) ([]hcl, []float64, error) {
 	bounds := img.Bounds()
-	pixelCount := (bounds.Max.X - bounds.Min.X) * (bounds.Max.Y - bounds.Min.Y)
-	colors := make([]hcl, pixelCount)
-	i := 0
-	var err error
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			if colors[i], err = toHCL(img.At(x, y)); err != nil {
-				return nil, fmt.Errorf("error translating pixel at (%v, %v): %w", x, y, err)
+	if cfg.region != nil {
+		bounds = bounds.Intersect(*cfg.region)
+		if bounds.Empty() {
+			return nil, nil, fmt.Errorf("region %v does not overlap image bounds %v", *cfg.region, img.Bounds())
+		}
+	}
+
+	weightFuncs := make([]WeightFunc, len(cfg.weighters))
+	for i, w := range cfg.weighters {
+		weightFuncs[i] = w(img, bounds)
+	}
+	weighted := cfg.mask != nil || len(weightFuncs) > 0
+
+	pixelCount := cfg.sampler.count(bounds)
+	colors := make([]hcl, 0, pixelCount)
+	var weights []float64
+	if weighted {
+		weights = make([]float64, 0, pixelCount)
+	}
+
+	read := newPixelReader(img, cfg.converter())
+	err := cfg.sampler.sample(bounds, func(x, y int) error {
+		if weighted {
+			weight := pixelWeight(x, y, img, cfg.mask, weightFuncs)
+			// Pixels with no weight are left out entirely, rather than
+			// included with zero weight, so that they cannot be picked as
+			// initial centroids.
+			if weight <= 0 {
+				return nil
 			}
-			i++
+			weights = append(weights, weight)
 		}
+		c, ok := read(x, y)
+		if !ok {
+			return fmt.Errorf("error translating pixel at (%v, %v): color has alpha channel 0", x, y)
+		}
+		colors = append(colors, c)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return colors, weights, nil
+}
+
+// pixelWeight multiplies together the weight of a pixel according to the
+// mask, if any, and each weight function.
+func pixelWeight(x, y int, img image.Image, mask image.Image, weightFuncs []WeightFunc) float64 {
+	weight := 1.0
+	if mask != nil {
+		weight = maskAlpha(mask, x, y)
+	}
+	if weight <= 0 || len(weightFuncs) == 0 {
+		return weight
+	}
+	pixel := img.At(x, y)
+	for _, fn := range weightFuncs {
+		weight *= fn(x, y, pixel)
+	}
+	return weight
+}
+
+// totalWeight sums the weights of count colors, treating a nil weights slice
+// as weighting every color equally.
+func totalWeight(weights []float64, count int) float64 {
+	if weights == nil {
+		return float64(count)
+	}
+	var total float64
+	for _, w := range weights {
+		total += w
 	}
-	return colors, nil
+	return total
 }
@@ -18,11 +18,10 @@ func Example() {
 		log.Fatal(err)
 	}
 
-	// For a real-world use case, it's best to use something like
-	// github.com/nfnt/resize to transform images into a manageable size before
-	// extracting colors:
+	// For a real-world use case, it's best to sample a bounded number of
+	// pixels from large images, e.g. by passing this option to Extract:
 	//
-	//     img = resize.Thumbnail(200, 200, img, resize.Lanczos3)
+	//     palettor.WithRandomSampling(40000)
 	//
 	// In this example, we're already starting from a tiny image.
 
@@ -3,7 +3,13 @@ package palettor
 import (
 	"bytes"
 	"encoding/base64"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	_ "image/jpeg"
 	"image/png"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,3 +31,125 @@ func TestExtract(t *testing.T) {
 	palette, _ := Extract(4, 100, img)
 	assert.Equal(t, 4, palette.Count())
 }
+
+// halvesImage returns a 4x2 image whose left half is red and right half blue.
+func halvesImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	draw.Draw(img, image.Rect(0, 0, 2, 2), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(2, 0, 4, 2), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestExtractWithRegion(t *testing.T) {
+	img := halvesImage()
+
+	palette, err := Extract(1, 100, img, WithRegion(image.Rect(0, 0, 2, 2)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, palette.Weight(red))
+
+	// Regions are clipped to the image bounds
+	palette, err = Extract(1, 100, img, WithRegion(image.Rect(3, -10, 100, 100)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, palette.Weight(blue))
+
+	_, err = Extract(1, 100, img, WithRegion(image.Rect(10, 10, 20, 20)))
+	assert.Error(t, err, "region outside the image should result in an error")
+}
+
+func TestExtractWithMask(t *testing.T) {
+	img := halvesImage()
+
+	// Weight one red pixel against one blue pixel, so that initialization
+	// always picks one centroid of each color.
+	mask := image.NewAlpha(img.Bounds())
+	mask.SetAlpha(0, 0, color.Alpha{255})
+	mask.SetAlpha(3, 1, color.Alpha{85})
+
+	palette, err := Extract(2, 100, img, WithMask(mask))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.75, palette.Weight(red), 0.0001, "mask alpha should weight pixels")
+	assert.InDelta(t, 0.25, palette.Weight(blue), 0.0001, "mask alpha should weight pixels")
+
+	// Fully transparent mask pixels are ignored
+	mask.SetAlpha(3, 1, color.Alpha{0})
+	palette, err = Extract(1, 100, img, WithMask(mask))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, palette.Weight(red))
+
+	_, err = Extract(1, 100, img, WithMask(image.NewAlpha(img.Bounds())))
+	assert.Error(t, err, "fully transparent mask should result in an error")
+}
+
+// genericImage hides the concrete type of an image, forcing getColors to use
+// the generic image.Image code path.
+type genericImage struct {
+	image.Image
+}
+
+func loadBenchmarkImage(b *testing.B) image.Image {
+	reader, err := os.Open("testdata/original.jpg")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer reader.Close()
+
+	img, _, err := image.Decode(reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return img
+}
+
+func benchmarkGetColors(b *testing.B, img image.Image) {
+	cfg := newConfig(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := getColors(img, cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetColorsGeneric(b *testing.B) {
+	benchmarkGetColors(b, genericImage{loadBenchmarkImage(b)})
+}
+
+func BenchmarkGetColorsYCbCr(b *testing.B) {
+	benchmarkGetColors(b, loadBenchmarkImage(b).(*image.YCbCr))
+}
+
+func BenchmarkGetColorsRGBA(b *testing.B) {
+	src := loadBenchmarkImage(b)
+	img := image.NewRGBA(src.Bounds())
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+	benchmarkGetColors(b, img)
+}
+
+func BenchmarkGetColorsNRGBA(b *testing.B) {
+	src := loadBenchmarkImage(b)
+	img := image.NewNRGBA(src.Bounds())
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+	benchmarkGetColors(b, img)
+}
+
+func BenchmarkGetColorsPaletted(b *testing.B) {
+	src := loadBenchmarkImage(b)
+	img := image.NewPaletted(src.Bounds(), palette.Plan9)
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+	benchmarkGetColors(b, img)
+}
+
+func BenchmarkGetColorsYCbCrCached(b *testing.B) {
+	img := loadBenchmarkImage(b)
+	cache := NewCache(1 << 16)
+	cfg := newConfig([]Option{WithCache(cache)})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := getColors(img, cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(cache.Stats().HitRate(), "hit-rate")
+}
@@ -0,0 +1,75 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+)
+
+// A converter converts alpha-premultiplied 16-bit channels to HCL, reporting
+// false if alpha is 0. See rgbaToHCL.
+type converter func(r, g, b, a uint32) (hcl, bool)
+
+// A pixelReader returns the HCL color of the pixel at (x, y), reporting false
+// if the pixel is fully transparent.
+type pixelReader func(x, y int) (hcl, bool)
+
+// newPixelReader returns a pixelReader for img, which uses convert to convert
+// each pixel to HCL. The common concrete image types are read directly from
+// their pixel buffers, avoiding the per-pixel allocation of a color.Color by
+// image.Image's At method. Every reader yields exactly the same colors as
+// converting the result of At.
+func newPixelReader(img image.Image, convert converter) pixelReader {
+	switch img := img.(type) {
+	case *image.RGBA:
+		return func(x, y int) (hcl, bool) {
+			i := img.PixOffset(x, y)
+			s := img.Pix[i : i+4 : i+4]
+			return convert(
+				uint32(s[0])*0x101,
+				uint32(s[1])*0x101,
+				uint32(s[2])*0x101,
+				uint32(s[3])*0x101,
+			)
+		}
+	case *image.NRGBA:
+		return func(x, y int) (hcl, bool) {
+			i := img.PixOffset(x, y)
+			s := img.Pix[i : i+4 : i+4]
+			return convert(color.NRGBA{s[0], s[1], s[2], s[3]}.RGBA())
+		}
+	case *image.YCbCr:
+		return func(x, y int) (hcl, bool) {
+			yi := img.YOffset(x, y)
+			ci := img.COffset(x, y)
+			return convert(color.YCbCr{img.Y[yi], img.Cb[ci], img.Cr[ci]}.RGBA())
+		}
+	case *image.Paletted:
+		// Paletted images have at most 256 distinct colors, so each one is
+		// only converted once.
+		colors := make([]hcl, len(img.Palette))
+		opaque := make([]bool, len(img.Palette))
+		for i, c := range img.Palette {
+			colors[i], opaque[i] = convert(c.RGBA())
+		}
+		return func(x, y int) (hcl, bool) {
+			index := img.Pix[img.PixOffset(x, y)]
+			return colors[index], opaque[index]
+		}
+	}
+	return func(x, y int) (hcl, bool) {
+		return convert(img.At(x, y).RGBA())
+	}
+}
+
+// maskAlpha returns the alpha value of mask at (x, y) in the range [0, 1],
+// reading *image.Alpha masks directly from their pixel buffers.
+func maskAlpha(mask image.Image, x, y int) float64 {
+	if m, ok := mask.(*image.Alpha); ok {
+		if !(image.Point{x, y}.In(m.Rect)) {
+			return 0
+		}
+		return float64(m.Pix[m.PixOffset(x, y)]) / 0xff
+	}
+	_, _, _, a := mask.At(x, y).RGBA()
+	return float64(a) / 0xffff
+}
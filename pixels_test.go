@@ -0,0 +1,102 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertReaderMatchesAt checks that the pixel reader for img yields exactly
+// the same colors as converting the result of img.At.
+func assertReaderMatchesAt(t *testing.T, img image.Image) {
+	t.Helper()
+	read := newPixelReader(img, rgbaToHCL)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			expected, expectedErr := toHCL(img.At(x, y))
+			actual, ok := read(x, y)
+			assert.Equal(t, expectedErr == nil, ok, "opacity mismatch at (%d, %d)", x, y)
+			assert.Equal(t, expected, actual, "color mismatch at (%d, %d)", x, y)
+		}
+	}
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	r.Read(b)
+	return b
+}
+
+func TestPixelReaderRGBA(t *testing.T) {
+	img := image.NewRGBA(image.Rect(-3, 2, 13, 18))
+	copy(img.Pix, randomBytes(len(img.Pix)))
+	// Keep the pixels valid alpha-premultiplied colors
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := img.Pix[i+3]
+		for j := 0; j < 3; j++ {
+			if img.Pix[i+j] > a {
+				img.Pix[i+j] = a
+			}
+		}
+	}
+	assertReaderMatchesAt(t, img)
+	assertReaderMatchesAt(t, img.SubImage(image.Rect(0, 5, 7, 9)))
+}
+
+func TestPixelReaderNRGBA(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	copy(img.Pix, randomBytes(len(img.Pix)))
+	img.Pix[3] = 0 // at least one fully transparent pixel
+	assertReaderMatchesAt(t, img)
+}
+
+func TestPixelReaderYCbCr(t *testing.T) {
+	for _, ratio := range []image.YCbCrSubsampleRatio{
+		image.YCbCrSubsampleRatio444,
+		image.YCbCrSubsampleRatio422,
+		image.YCbCrSubsampleRatio420,
+		image.YCbCrSubsampleRatio440,
+		image.YCbCrSubsampleRatio411,
+		image.YCbCrSubsampleRatio410,
+	} {
+		img := image.NewYCbCr(image.Rect(1, 1, 17, 13), ratio)
+		copy(img.Y, randomBytes(len(img.Y)))
+		copy(img.Cb, randomBytes(len(img.Cb)))
+		copy(img.Cr, randomBytes(len(img.Cr)))
+		assertReaderMatchesAt(t, img)
+	}
+}
+
+func TestPixelReaderPaletted(t *testing.T) {
+	p := append(color.Palette{color.Transparent}, palette.WebSafe...)
+	img := image.NewPaletted(image.Rect(0, 0, 16, 16), p)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.Intn(len(p)))
+	}
+	img.Pix[0] = 0
+	assertReaderMatchesAt(t, img)
+}
+
+func TestPixelReaderGeneric(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 8, 8))
+	copy(img.Pix, randomBytes(len(img.Pix)))
+	assertReaderMatchesAt(t, img)
+}
+
+func TestMaskAlpha(t *testing.T) {
+	mask := image.NewAlpha(image.Rect(0, 0, 2, 1))
+	mask.SetAlpha(0, 0, color.Alpha{255})
+	mask.SetAlpha(1, 0, color.Alpha{51})
+	assert.Equal(t, 1.0, maskAlpha(mask, 0, 0))
+	assert.InDelta(t, 0.2, maskAlpha(mask, 1, 0), 0.0001)
+	assert.Equal(t, 0.0, maskAlpha(mask, 5, 5), "pixels outside the mask should have no weight")
+
+	generic := image.NewAlpha16(image.Rect(0, 0, 1, 1))
+	generic.SetAlpha16(0, 0, color.Alpha16{0x8000})
+	assert.InDelta(t, 0.5, maskAlpha(generic, 0, 0), 0.0001)
+	assert.Equal(t, 0.0, maskAlpha(generic, 5, 5))
+}
@@ -0,0 +1,108 @@
+package palettor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// Quality describes how faithfully a Palette represents an image, as measured
+// by mapping every pixel of the image to its nearest palette entry. It can be
+// used to tune k or to compare the output of different extraction settings.
+type Quality struct {
+	// MSE is the mean squared error between each pixel and its nearest
+	// palette color, averaged over the R, G and B channels in the 0-255
+	// interval.
+	MSE float64
+
+	// PSNR is the peak signal-to-noise ratio in decibels derived from MSE.
+	// Higher is better; it is +Inf if the palette reproduces the image
+	// exactly.
+	PSNR float64
+
+	// MeanDeltaE and P95DeltaE are the mean and 95th percentile of the
+	// CIEDE2000 color difference between each pixel and its nearest palette
+	// color. A difference of about 2.3 is just noticeable.
+	MeanDeltaE float64
+	P95DeltaE  float64
+
+	// Inertia is the within-cluster sum of squared distances, using the same
+	// distance metric as the clustering algorithm.
+	Inertia float64
+}
+
+// String returns a human-readable summary of the quality metrics.
+func (q *Quality) String() string {
+	return fmt.Sprintf(
+		"mse=%.4f psnr=%.2fdB mean_delta_e=%.4f p95_delta_e=%.4f inertia=%.4f",
+		q.MSE, q.PSNR, q.MeanDeltaE, q.P95DeltaE, q.Inertia,
+	)
+}
+
+// Quality maps every pixel in img to its nearest entry in p and reports how
+// well the palette reconstructs the image. The pixels of img are interpreted
+// in the source profile of the palette.
+func (p *Palette) Quality(img image.Image) (*Quality, error) {
+	if p.Count() == 0 {
+		return nil, errors.New("cannot measure quality of an empty palette")
+	}
+	colors, _, err := getColors(img, newConfig([]Option{WithSourceProfile(p.profile)}))
+	if err != nil {
+		return nil, fmt.Errorf("error extracting colors from image: %w", err)
+	}
+	if len(colors) == 0 {
+		return nil, errors.New("cannot measure quality against an empty image")
+	}
+
+	centroids := make([]hcl, 0, p.Count())
+	for _, entry := range p.Entries() {
+		c, err := toHCL(entry.Color)
+		if err != nil {
+			return nil, fmt.Errorf("error translating palette color: %w", err)
+		}
+		centroids = append(centroids, c)
+	}
+
+	var (
+		q           Quality
+		squaredErr  float64
+		sumDeltaE   float64
+		deltaEs     = make([]float64, len(colors))
+		pixelCount  = float64(len(colors))
+		channelSize = 255.0
+	)
+	for i, c := range colors {
+		centroid := nearest(c, centroids)
+
+		actual := c.colorful().Clamped()
+		approx := centroid.colorful().Clamped()
+		dr := (actual.R - approx.R) * channelSize
+		dg := (actual.G - approx.G) * channelSize
+		db := (actual.B - approx.B) * channelSize
+		squaredErr += dr*dr + dg*dg + db*db
+
+		deltaEs[i] = c.deltaE(centroid)
+		sumDeltaE += deltaEs[i]
+
+		q.Inertia += c.distanceSquared(centroid)
+	}
+
+	q.MSE = squaredErr / (3 * pixelCount)
+	q.PSNR = 10 * math.Log10(channelSize*channelSize/q.MSE)
+	q.MeanDeltaE = sumDeltaE / pixelCount
+	q.P95DeltaE = percentile(deltaEs, 0.95)
+	return &q, nil
+}
+
+// percentile returns the nearest-rank percentile p, in the interval (0, 1], of
+// the given values. The values are sorted in place.
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := int(math.Ceil(p*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
@@ -0,0 +1,72 @@
+package palettor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuality(t *testing.T) {
+	decoder := base64.NewDecoder(base64.StdEncoding, bytes.NewReader(testImageData))
+	img, err := png.Decode(decoder)
+	if err != nil {
+		t.Fatalf("invalid test image: %s", err)
+	}
+
+	// A palette with one entry per distinct pixel reproduces the image exactly
+	palette, err := Extract(4, 100, img)
+	assert.NoError(t, err)
+	quality, err := palette.Quality(img)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, quality.MSE, 0.0001)
+	assert.True(t, math.IsInf(quality.PSNR, 1), "exact reconstruction should have infinite PSNR")
+	assert.InDelta(t, 0, quality.MeanDeltaE, 0.0001)
+	assert.InDelta(t, 0, quality.P95DeltaE, 0.0001)
+	assert.InDelta(t, 0, quality.Inertia, 0.0001)
+
+	// A smaller palette must lose some information
+	palette, err = Extract(2, 100, img)
+	assert.NoError(t, err)
+	quality, err = palette.Quality(img)
+	assert.NoError(t, err)
+	assert.Greater(t, quality.MSE, 0.0)
+	assert.False(t, math.IsInf(quality.PSNR, 1))
+	assert.Greater(t, quality.MeanDeltaE, 0.0)
+	assert.GreaterOrEqual(t, quality.P95DeltaE, quality.MeanDeltaE)
+	assert.Greater(t, quality.Inertia, 0.0)
+	assert.NotEmpty(t, quality.String())
+
+	_, err = (&Palette{}).Quality(img)
+	assert.Error(t, err, "empty palette should result in an error")
+
+	_, err = palette.Quality(image.NewRGBA(image.Rect(0, 0, 0, 0)))
+	assert.Error(t, err, "empty image should result in an error")
+}
+
+func TestQualityKnownError(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{0, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{10, 10, 10, 255})
+
+	palette := &Palette{}
+	palette.add(black, 1)
+
+	quality, err := palette.Quality(img)
+	assert.NoError(t, err)
+	// Half the pixels are off by 10 in each channel
+	assert.InDelta(t, 50, quality.MSE, 0.01)
+	assert.InDelta(t, 10*math.Log10(255*255/50.0), quality.PSNR, 0.01)
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+	assert.Equal(t, 5.0, percentile(values, 0.95))
+	assert.Equal(t, 3.0, percentile(values, 0.5))
+	assert.Equal(t, 1.0, percentile(values, 0.01))
+}
@@ -0,0 +1,177 @@
+package palettor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// A swatch describes a palette entry for display in a report.
+type swatch struct {
+	Hex           string
+	RGB           string
+	HCL           string
+	Weight        string
+	Name          string
+	ContrastBlack string
+	ContrastWhite string
+	// Text is the color, black or white, that is most legible on the swatch.
+	Text string
+	// Y is the vertical offset of the swatch in an SVG report.
+	Y int
+}
+
+// reportData is the input of the report templates.
+type reportData struct {
+	Title     string
+	Thumbnail template.URL
+	Swatches  []swatch
+	// Dimensions of the SVG report and of its thumbnail
+	Width, Height   int
+	ThumbnailHeight int
+}
+
+// Layout of SVG reports, in pixels
+const (
+	svgWidth        = 640
+	svgMargin       = 20
+	svgTitleHeight  = 40
+	svgSwatchHeight = 80
+	svgSwatchGap    = 10
+)
+
+// newReportData describes the given entries and thumbnail, which may be nil,
+// for the report templates.
+func newReportData(title string, entries []Entry, thumbnail image.Image) (*reportData, error) {
+	data := &reportData{Title: title, Width: svgWidth}
+	y := svgMargin + svgTitleHeight
+	if thumbnail != nil {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, thumbnail); err != nil {
+			return nil, fmt.Errorf("error encoding thumbnail: %w", err)
+		}
+		data.Thumbnail = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+		bounds := thumbnail.Bounds()
+		if !bounds.Empty() {
+			data.ThumbnailHeight = (svgWidth - 2*svgMargin) * bounds.Dy() / bounds.Dx()
+		}
+		y += data.ThumbnailHeight + svgMargin
+	}
+
+	for _, e := range entries {
+		r, g, b := e.colorful().RGB255()
+		c := entryHCL(e)
+		black := ContrastRatio(e.Color, color.Black)
+		white := ContrastRatio(e.Color, color.White)
+		text := "#000000"
+		if white > black {
+			text = "#ffffff"
+		}
+		data.Swatches = append(data.Swatches, swatch{
+			Hex:           e.Hex(),
+			RGB:           fmt.Sprintf("rgb(%d, %d, %d)", r, g, b),
+			HCL:           fmt.Sprintf("hcl(%.1f, %.3f, %.3f)", c.h, c.c, c.l),
+			Weight:        weightComment(e),
+			Name:          entryName(e),
+			ContrastBlack: fmt.Sprintf("%.2f:1", black),
+			ContrastWhite: fmt.Sprintf("%.2f:1", white),
+			Text:          text,
+			Y:             y,
+		})
+		y += svgSwatchHeight + svgSwatchGap
+	}
+	data.Height = y - svgSwatchGap + svgMargin
+	return data, nil
+}
+
+var svgReport = template.Must(template.New("svg").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" font-family="sans-serif">
+  <rect width="100%" height="100%" fill="#ffffff"/>
+  <text x="20" y="44" font-size="20" font-weight="bold" fill="#000000">{{.Title}}</text>
+{{- if .Thumbnail}}
+  <image x="20" y="60" width="600" height="{{.ThumbnailHeight}}" href="{{.Thumbnail}}"/>
+{{- end}}
+{{- range .Swatches}}
+  <g transform="translate(20, {{.Y}})">
+    <rect width="160" height="80" fill="{{.Hex}}" stroke="#cccccc"/>
+    <text x="80" y="45" font-size="14" text-anchor="middle" fill="{{.Text}}">{{.Weight}}</text>
+    <text x="180" y="18" font-size="16" font-weight="bold" fill="#000000">{{.Name}} {{.Hex}}</text>
+    <text x="180" y="40" font-size="13" fill="#333333">{{.RGB}} · {{.HCL}}</text>
+    <text x="180" y="62" font-size="13" fill="#333333">contrast {{.ContrastBlack}} on black · {{.ContrastWhite}} on white</text>
+  </g>
+{{- end}}
+</svg>
+`))
+
+var htmlReport = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: sans-serif; margin: 2em; color: #222; }
+  .thumbnail { max-width: 600px; display: block; margin-bottom: 2em; }
+  .swatches { display: grid; grid-template-columns: repeat(auto-fill, minmax(220px, 1fr)); gap: 1em; }
+  .swatch { border: 1px solid #ccc; border-radius: 4px; overflow: hidden; }
+  .chip { height: 100px; display: flex; align-items: center; justify-content: center; font-size: 1.5em; }
+  table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+  th, td { text-align: left; padding: 0.25em 0.5em; }
+  th { color: #666; font-weight: normal; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Thumbnail}}
+<img class="thumbnail" src="{{.Thumbnail}}" alt="Source image">
+{{- end}}
+<div class="swatches">
+{{- range .Swatches}}
+  <div class="swatch">
+    <div class="chip" style="background-color: {{.Hex}}; color: {{.Text}}">{{.Weight}}</div>
+    <table>
+      <tr><th>Name</th><td>{{.Name}}</td></tr>
+      <tr><th>Hex</th><td>{{.Hex}}</td></tr>
+      <tr><th>RGB</th><td>{{.RGB}}</td></tr>
+      <tr><th>HCL</th><td>{{.HCL}}</td></tr>
+      <tr><th>Weight</th><td>{{.Weight}}</td></tr>
+      <tr><th>Contrast on black</th><td>{{.ContrastBlack}}</td></tr>
+      <tr><th>Contrast on white</th><td>{{.ContrastWhite}}</td></tr>
+    </table>
+  </div>
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+// EncodeSVG writes the given entries, in order, as a standalone SVG swatch
+// sheet with the given title. Each swatch is labeled with its hex, RGB and HCL
+// values, weight, name (or nearest CSS color name), and WCAG contrast ratio against
+// black and white.
+//
+// If thumbnail is not nil, it is embedded above the swatches as a PNG. It is
+// embedded as is, so it should be a small copy of the source image.
+func EncodeSVG(w io.Writer, title string, entries []Entry, thumbnail image.Image) error {
+	data, err := newReportData(title, entries, thumbnail)
+	if err != nil {
+		return err
+	}
+	return svgReport.Execute(w, data)
+}
+
+// EncodeHTML writes the given entries, in order, as a self-contained HTML
+// report with the given title, describing each swatch like EncodeSVG does.
+//
+// If thumbnail is not nil, it is embedded above the swatches as a PNG. It is
+// embedded as is, so it should be a small copy of the source image.
+func EncodeHTML(w io.Writer, title string, entries []Entry, thumbnail image.Image) error {
+	data, err := newReportData(title, entries, thumbnail)
+	if err != nil {
+		return err
+	}
+	return htmlReport.Execute(w, data)
+}
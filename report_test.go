@@ -0,0 +1,75 @@
+package palettor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertThumbnail checks that a report embeds a decodable thumbnail of the
+// product image.
+func assertThumbnail(t *testing.T, report string) {
+	t.Helper()
+	match := regexp.MustCompile(`data:image/png;base64,([^"]+)"`).FindStringSubmatch(report)
+	if !assert.NotNil(t, match, "report should embed a thumbnail") {
+		return
+	}
+	// html/template escapes "+" as an entity
+	data := strings.Replace(match[1], "&#43;", "+", -1)
+	img, err := png.Decode(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, productImage().Bounds(), img.Bounds())
+}
+
+func TestEncodeSVG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeSVG(&buf, "Steel & snow", codegenEntries, productImage()))
+	report := buf.String()
+
+	// The report is well-formed XML
+	decoder := xml.NewDecoder(strings.NewReader(report))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+	}
+
+	assert.Contains(t, report, "Steel &amp; snow")
+	assert.Contains(t, report, `fill="#4682b4"`)
+	assert.Contains(t, report, "steelblue #4682b4")
+	assert.Contains(t, report, "rgb(70, 130, 180)")
+	assert.Contains(t, report, "25.00%")
+	assert.Contains(t, report, "contrast 5.11:1 on black · 4.11:1 on white")
+	assertThumbnail(t, report)
+
+	// The thumbnail is optional
+	buf.Reset()
+	assert.NoError(t, EncodeSVG(&buf, "No thumbnail", codegenEntries, nil))
+	assert.NotContains(t, buf.String(), "<image")
+}
+
+func TestEncodeHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeHTML(&buf, "<Product>", codegenEntries, productImage()))
+	report := buf.String()
+
+	assert.True(t, strings.HasPrefix(report, "<!DOCTYPE html>"))
+	assert.Contains(t, report, "<title>&lt;Product&gt;</title>")
+	assert.Contains(t, report, "background-color: #fafafa; color: #000000")
+	assert.Contains(t, report, "<td>white</td>")
+	assert.Contains(t, report, "<td>hcl(262.8, 0.325, 0.525)</td>")
+	assert.Contains(t, report, "<td>20.12:1</td>")
+	assert.Equal(t, 3, strings.Count(report, `<div class="swatch">`))
+	assertThumbnail(t, report)
+}
@@ -0,0 +1,147 @@
+package palettor
+
+import (
+	"image"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// A sampler chooses which pixels of an image are considered by Extract.
+type sampler interface {
+	// count returns the number of pixels that will be sampled from bounds.
+	count(bounds image.Rectangle) int
+	// sample calls visit for each sampled pixel in bounds, stopping at the
+	// first error.
+	sample(bounds image.Rectangle, visit func(x, y int) error) error
+}
+
+// fullSampler samples every pixel.
+type fullSampler struct{}
+
+func (fullSampler) count(bounds image.Rectangle) int {
+	return bounds.Dx() * bounds.Dy()
+}
+
+func (fullSampler) sample(bounds image.Rectangle, visit func(x, y int) error) error {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if err := visit(x, y); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// gridSampler samples every stride-th pixel in each direction.
+type gridSampler struct {
+	stride int
+}
+
+func (s gridSampler) count(bounds image.Rectangle) int {
+	return ceilDiv(bounds.Dx(), s.stride) * ceilDiv(bounds.Dy(), s.stride)
+}
+
+func (s gridSampler) sample(bounds image.Rectangle, visit func(x, y int) error) error {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += s.stride {
+		for x := bounds.Min.X; x < bounds.Max.X; x += s.stride {
+			if err := visit(x, y); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// randomSampler samples n distinct pixels chosen uniformly at random.
+type randomSampler struct {
+	n int
+}
+
+func (s randomSampler) count(bounds image.Rectangle) int {
+	return minInt(s.n, bounds.Dx()*bounds.Dy())
+}
+
+func (s randomSampler) sample(bounds image.Rectangle, visit func(x, y int) error) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return visitRandom(r, bounds, s.n, visit)
+}
+
+// tileSampler divides the image into a grid of tiles*tiles equally sized
+// tiles and samples perTile distinct pixels at random from each one, which
+// guarantees that every part of the image is represented.
+type tileSampler struct {
+	tiles, perTile int
+}
+
+func (s tileSampler) count(bounds image.Rectangle) int {
+	var total int
+	s.eachTile(bounds, func(tile image.Rectangle) {
+		total += minInt(s.perTile, tile.Dx()*tile.Dy())
+	})
+	return total
+}
+
+func (s tileSampler) sample(bounds image.Rectangle, visit func(x, y int) error) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var err error
+	s.eachTile(bounds, func(tile image.Rectangle) {
+		if err == nil {
+			err = visitRandom(r, tile, s.perTile, visit)
+		}
+	})
+	return err
+}
+
+func (s tileSampler) eachTile(bounds image.Rectangle, fn func(image.Rectangle)) {
+	for ty := 0; ty < s.tiles; ty++ {
+		for tx := 0; tx < s.tiles; tx++ {
+			tile := image.Rect(
+				bounds.Min.X+tx*bounds.Dx()/s.tiles,
+				bounds.Min.Y+ty*bounds.Dy()/s.tiles,
+				bounds.Min.X+(tx+1)*bounds.Dx()/s.tiles,
+				bounds.Min.Y+(ty+1)*bounds.Dy()/s.tiles,
+			)
+			if !tile.Empty() {
+				fn(tile)
+			}
+		}
+	}
+}
+
+// visitRandom calls visit for n distinct pixels of bounds chosen uniformly at
+// random, or for every pixel if there are fewer than n. Pixels are visited in
+// row-major order.
+func visitRandom(r *rand.Rand, bounds image.Rectangle, n int, visit func(x, y int) error) error {
+	total := bounds.Dx() * bounds.Dy()
+	if n >= total {
+		return fullSampler{}.sample(bounds, visit)
+	}
+
+	// Robert Floyd's algorithm picks n distinct indexes in O(n) time and
+	// space, regardless of the size of the image.
+	chosen := make(map[int]struct{}, n)
+	indexes := make([]int, 0, n)
+	for j := total - n; j < total; j++ {
+		i := r.Intn(j + 1)
+		if _, ok := chosen[i]; ok {
+			i = j
+		}
+		chosen[i] = struct{}{}
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	w := bounds.Dx()
+	for _, i := range indexes {
+		if err := visit(bounds.Min.X+i%w, bounds.Min.Y+i/w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,122 @@
+package palettor
+
+import (
+	"image"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collect returns every point visited by a sampler, asserting that the
+// sampler's count is accurate.
+func collect(t *testing.T, s sampler, bounds image.Rectangle) []image.Point {
+	t.Helper()
+	var points []image.Point
+	err := s.sample(bounds, func(x, y int) error {
+		points = append(points, image.Pt(x, y))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, s.count(bounds), len(points), "count should match the number of sampled pixels")
+	return points
+}
+
+func assertDistinctAndInBounds(t *testing.T, points []image.Point, bounds image.Rectangle) {
+	t.Helper()
+	seen := make(map[image.Point]bool)
+	for _, p := range points {
+		assert.True(t, p.In(bounds), "sampled pixel %v out of bounds %v", p, bounds)
+		assert.False(t, seen[p], "pixel %v sampled more than once", p)
+		seen[p] = true
+	}
+}
+
+func TestFullSampler(t *testing.T) {
+	bounds := image.Rect(1, 2, 4, 4)
+	points := collect(t, fullSampler{}, bounds)
+	assert.Len(t, points, 6)
+	assertDistinctAndInBounds(t, points, bounds)
+}
+
+func TestGridSampler(t *testing.T) {
+	bounds := image.Rect(10, 10, 15, 13)
+	points := collect(t, gridSampler{stride: 2}, bounds)
+	assert.Equal(t, []image.Point{
+		{10, 10}, {12, 10}, {14, 10},
+		{10, 12}, {12, 12}, {14, 12},
+	}, points)
+}
+
+func TestRandomSampler(t *testing.T) {
+	bounds := image.Rect(-5, -5, 95, 45)
+	points := collect(t, randomSampler{n: 500}, bounds)
+	assert.Len(t, points, 500)
+	assertDistinctAndInBounds(t, points, bounds)
+
+	// Asking for more pixels than there are samples every pixel
+	points = collect(t, randomSampler{n: 50}, image.Rect(0, 0, 5, 5))
+	assert.Len(t, points, 25)
+}
+
+func TestVisitRandomIsUniform(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	bounds := image.Rect(0, 0, 4, 1)
+	counts := make(map[int]int)
+	for i := 0; i < 4000; i++ {
+		_ = visitRandom(r, bounds, 1, func(x, y int) error {
+			counts[x]++
+			return nil
+		})
+	}
+	for x := 0; x < 4; x++ {
+		assert.InDelta(t, 1000, counts[x], 150, "pixel %d should be sampled about a quarter of the time", x)
+	}
+}
+
+func TestTileSampler(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 60)
+	s := tileSampler{tiles: 4, perTile: 10}
+	points := collect(t, s, bounds)
+	assert.Len(t, points, 160)
+	assertDistinctAndInBounds(t, points, bounds)
+
+	// Every tile is represented
+	perTile := make(map[image.Point]int)
+	for _, p := range points {
+		perTile[image.Pt(p.X/25, p.Y/15)]++
+	}
+	assert.Len(t, perTile, 16)
+	for tile, count := range perTile {
+		assert.Equal(t, 10, count, "tile %v", tile)
+	}
+
+	// Tiny images yield fewer, smaller tiles
+	points = collect(t, tileSampler{tiles: 4, perTile: 10}, image.Rect(0, 0, 2, 2))
+	assert.Len(t, points, 4)
+}
+
+func TestExtractWithSampling(t *testing.T) {
+	reader, err := os.Open("testdata/original.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	img, _, err := image.Decode(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opt := range []Option{
+		WithGridSampling(8),
+		WithRandomSampling(5000),
+		WithTileSampling(8, 80),
+	} {
+		palette, err := Extract(3, 100, img, opt)
+		assert.NoError(t, err)
+		// Clusters that converge to the same color collapse into one entry.
+		assert.True(t, palette.Count() > 0 && palette.Count() <= 3, "unexpected palette size %d", palette.Count())
+		assert.Equal(t, 3, palette.K())
+	}
+}
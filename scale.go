@@ -0,0 +1,149 @@
+package palettor
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+)
+
+// A ScaleSpace is the perceptual color space in which the lightness of a
+// color is varied to build a tonal scale.
+type ScaleSpace int
+
+const (
+	// ScaleHCL varies lightness in HCL space, keeping HCL hue and chroma.
+	// This is the default.
+	ScaleHCL ScaleSpace = iota
+	// ScaleOklch varies lightness in Oklch, the polar form of Oklab, whose
+	// hues stay truer to the original color, especially for blues.
+	ScaleOklch
+)
+
+var scaleSpaceNames = []string{
+	ScaleHCL:   "hcl",
+	ScaleOklch: "oklch",
+}
+
+// String returns the name of a ScaleSpace, as accepted by ParseScaleSpace.
+func (s ScaleSpace) String() string {
+	return enumString(scaleSpaceNames, "ScaleSpace", int(s))
+}
+
+// ParseScaleSpace returns the ScaleSpace with the given name.
+func ParseScaleSpace(name string) (ScaleSpace, error) {
+	i, err := parseEnum(scaleSpaceNames, "scale space", name)
+	return ScaleSpace(i), err
+}
+
+// tone returns the color with the given lightness, in the [0, 1] interval, and
+// the hue of c, with as much of the chroma of c as the sRGB gamut allows.
+func (s ScaleSpace) tone(c hcl, lightness float64) hcl {
+	if s != ScaleOklch {
+		return clipChroma(hcl{c.h, c.c, lightness})
+	}
+
+	lab := toOklab(c)
+	chroma, hue := math.Hypot(lab[1], lab[2]), math.Atan2(lab[2], lab[1])
+	oklch := func(chroma float64) [3]float64 {
+		return [3]float64{lightness, chroma * math.Cos(hue), chroma * math.Sin(hue)}
+	}
+	inGamut := func(chroma float64) bool {
+		const epsilon = 1e-9
+		r, g, b := oklabToLinearRGB(oklch(chroma))
+		return r >= -epsilon && r <= 1+epsilon &&
+			g >= -epsilon && g <= 1+epsilon &&
+			b >= -epsilon && b <= 1+epsilon
+	}
+	if !inGamut(chroma) {
+		low, high := 0.0, chroma
+		for i := 0; i < 24; i++ {
+			mid := (low + high) / 2
+			if inGamut(mid) {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+		chroma = low
+	}
+	return fromColorful(fromOklab(oklch(chroma)).colorful().Clamped())
+}
+
+// A Tone is a step of a tonal scale.
+type Tone struct {
+	// Step is the position of the tone in the scale, from 0 for white to 1000
+	// for black, as in the 50-900 scales of design systems.
+	Step  int
+	Color color.Color
+}
+
+// MarshalJSON encodes a tone as its step and hex value.
+func (t Tone) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Step int    `json:"step"`
+		Hex  string `json:"hex"`
+	}{t.Step, Entry{Color: t.Color}.Hex()})
+}
+
+// A Scale is a tonal scale of tints and shades of a palette entry.
+type Scale struct {
+	Entry Entry  `json:"entry"`
+	Tones []Tone `json:"tones"`
+}
+
+// MaxScaleSteps is the largest number of steps in a tonal scale, beyond which
+// steps rounded to multiples of 10 would repeat.
+const MaxScaleSteps = 99
+
+// ScaleSteps returns the steps of an n-step tonal scale. Ten steps give the
+// familiar 50, 100, 200, ... 900 scale; other numbers of steps are spread
+// evenly between 0 and 1000 and rounded to multiples of 10, so that nine
+// steps give 100, 200, ... 900. Scales of more than MaxScaleSteps steps are
+// capped to MaxScaleSteps, so that every step is distinct.
+func ScaleSteps(n int) []int {
+	if n <= 0 {
+		return nil
+	}
+	if n > MaxScaleSteps {
+		n = MaxScaleSteps
+	}
+	steps := make([]int, n)
+	if n == 10 {
+		steps[0] = 50
+		for i := 1; i < n; i++ {
+			steps[i] = 100 * i
+		}
+		return steps
+	}
+	for i := range steps {
+		steps[i] = 10 * int(math.Round(100*float64(i+1)/float64(n+1)))
+	}
+	return steps
+}
+
+// Scale builds an n-step tonal scale from an entry, from its lightest tint to
+// its darkest shade, with the steps given by ScaleSteps. The lightness of each
+// tone is 1 - step/1000 in the given space, so that step 500 has a medium
+// lightness, while the hue of the entry is kept, and its chroma is reduced
+// where needed to stay within the sRGB gamut.
+func (e Entry) Scale(n int, space ScaleSpace) Scale {
+	c := entryHCL(e)
+	scale := Scale{Entry: e}
+	for _, step := range ScaleSteps(n) {
+		scale.Tones = append(scale.Tones, Tone{
+			Step:  step,
+			Color: space.tone(c, 1-float64(step)/1000),
+		})
+	}
+	return scale
+}
+
+// Scales builds an n-step tonal scale from each of the given entries, in order.
+// See Entry.Scale.
+func Scales(entries []Entry, n int, space ScaleSpace) []Scale {
+	scales := make([]Scale, len(entries))
+	for i, e := range entries {
+		scales[i] = e.Scale(n, space)
+	}
+	return scales
+}
@@ -0,0 +1,83 @@
+package palettor
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleSteps(t *testing.T) {
+	assert.Equal(t, []int{50, 100, 200, 300, 400, 500, 600, 700, 800, 900}, ScaleSteps(10))
+	assert.Equal(t, []int{100, 200, 300, 400, 500, 600, 700, 800, 900}, ScaleSteps(9))
+	assert.Equal(t, []int{250, 500, 750}, ScaleSteps(3))
+	assert.Equal(t, []int{500}, ScaleSteps(1))
+	assert.Nil(t, ScaleSteps(0))
+
+	// Steps never repeat
+	for n := 1; n <= MaxScaleSteps+1; n++ {
+		steps := ScaleSteps(n)
+		for i := 1; i < len(steps); i++ {
+			assert.Less(t, steps[i-1], steps[i], "%d steps", n)
+		}
+	}
+	assert.Len(t, ScaleSteps(100), MaxScaleSteps)
+}
+
+func TestScale(t *testing.T) {
+	steelblue := Entry{Color: forceHCL(color.RGBA{70, 130, 180, 255}), Weight: 0.5, Name: "steelblue"}
+	for _, space := range []ScaleSpace{ScaleHCL, ScaleOklch} {
+		scale := steelblue.Scale(10, space)
+		assert.Equal(t, steelblue, scale.Entry)
+		assert.Len(t, scale.Tones, 10)
+
+		var hueDrift float64
+		for i, tone := range scale.Tones {
+			assert.Equal(t, ScaleSteps(10)[i], tone.Step)
+			c := tone.Color.(hcl)
+			rgb := c.colorful()
+			for _, v := range []float64{rgb.R, rgb.G, rgb.B} {
+				assert.True(t, v > -1e-6 && v < 1+1e-6, "%s %d: tones are within the sRGB gamut", space, tone.Step)
+			}
+			if i > 0 {
+				previous := scale.Tones[i-1].Color
+				assert.True(t, relativeLuminance(tone.Color) < relativeLuminance(previous), "%s %d: tones get darker", space, tone.Step)
+			}
+			// Tones keep the hue, except for the washed out extremes
+			if tone.Step >= 200 && tone.Step <= 800 {
+				hueDrift = math.Max(hueDrift, c.hueDistance(entryHCL(steelblue)))
+			}
+		}
+		// Oklch hues differ from HCL hues, mostly for blues
+		assert.True(t, hueDrift < 10, "%s: hue drifted by %v", space, hueDrift)
+
+		// Tones of medium lightness keep the chroma of the entry, which
+		// lighter and darker tones lose to the gamut
+		tone500 := scale.Tones[5].Color.(hcl)
+		assert.InDelta(t, entryHCL(steelblue).c, tone500.c, 0.05, space.String())
+		assert.True(t, scale.Tones[0].Color.(hcl).c < tone500.c, space.String())
+	}
+
+	// In HCL, lightness follows the step exactly
+	scale := steelblue.Scale(9, ScaleHCL)
+	for _, tone := range scale.Tones {
+		assert.InDelta(t, 1-float64(tone.Step)/1000, tone.Color.(hcl).l, 1e-6)
+	}
+
+	grey := Entry{Color: forceHCL(color.RGBA{128, 128, 128, 255})}
+	for _, tone := range grey.Scale(5, ScaleOklch).Tones {
+		r, g, b := Entry{Color: tone.Color}.RGB()
+		assert.InDelta(t, r, g, 1e-6)
+		assert.InDelta(t, g, b, 1e-6)
+	}
+
+	scales := Scales([]Entry{steelblue, grey}, 3, ScaleHCL)
+	assert.Len(t, scales, 2)
+	assert.Equal(t, grey, scales[1].Entry)
+
+	data, err := json.Marshal(scales[0].Tones[1])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"step": 500, "hex": "`+Entry{Color: scales[0].Tones[1].Color}.Hex()+`"}`, string(data))
+}
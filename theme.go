@@ -0,0 +1,296 @@
+package palettor
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// A Role is a part that a color can play in a user interface theme, after the
+// swatches of Android's Palette API.
+type Role int
+
+const (
+	// Vibrant is a saturated color of medium lightness.
+	Vibrant Role = iota
+	// LightVibrant is a saturated, light color.
+	LightVibrant
+	// DarkVibrant is a saturated, dark color.
+	DarkVibrant
+	// Muted is a desaturated color of medium lightness.
+	Muted
+	// LightMuted is a desaturated, light color.
+	LightMuted
+	// DarkMuted is a desaturated, dark color.
+	DarkMuted
+)
+
+// AllRoles are all the Roles, in the order in which they are assigned.
+var AllRoles = []Role{Vibrant, LightVibrant, DarkVibrant, Muted, LightMuted, DarkMuted}
+
+var roleNames = []string{
+	Vibrant:      "vibrant",
+	LightVibrant: "light-vibrant",
+	DarkVibrant:  "dark-vibrant",
+	Muted:        "muted",
+	LightMuted:   "light-muted",
+	DarkMuted:    "dark-muted",
+}
+
+// String returns the name of a Role, as accepted by ParseRole.
+func (r Role) String() string {
+	return enumString(roleNames, "Role", int(r))
+}
+
+// ParseRole returns the Role with the given name.
+func ParseRole(name string) (Role, error) {
+	i, err := parseEnum(roleNames, "role", name)
+	return Role(i), err
+}
+
+// vibrant reports whether a Role calls for a saturated color.
+func (r Role) vibrant() bool {
+	return r == Vibrant || r == LightVibrant || r == DarkVibrant
+}
+
+// A roleTarget describes the HCL lightness and chroma of the colors that suit
+// a Role: colors outside the min-max bounds are unsuitable, and the closer a
+// color is to the target, the better.
+type roleTarget struct {
+	minL, targetL, maxL float64
+	minC, targetC, maxC float64
+}
+
+// The lightness targets follow Android's Palette API, with chroma bounds that
+// play the part of its saturation bounds. Outer bounds are open, since HCL
+// values can stray just outside their nominal range.
+var roleTargets = map[Role]roleTarget{
+	Vibrant:      {0.3, 0.5, 0.7, 0.35, 0.8, math.Inf(1)},
+	LightVibrant: {0.55, 0.74, math.Inf(1), 0.35, 0.8, math.Inf(1)},
+	DarkVibrant:  {math.Inf(-1), 0.26, 0.45, 0.35, 0.8, math.Inf(1)},
+	Muted:        {0.3, 0.5, 0.7, math.Inf(-1), 0.2, 0.35},
+	LightMuted:   {0.55, 0.74, math.Inf(1), math.Inf(-1), 0.2, 0.35},
+	DarkMuted:    {math.Inf(-1), 0.26, 0.45, math.Inf(-1), 0.2, 0.35},
+}
+
+// Relative importance of chroma, lightness and weight in scoring colors for a
+// role
+const (
+	roleChromaWeight    = 0.24
+	roleLightnessWeight = 0.52
+	roleWeightWeight    = 0.24
+)
+
+func (t roleTarget) accepts(c hcl) bool {
+	return c.l >= t.minL && c.l <= t.maxL && c.c >= t.minC && c.c <= t.maxC
+}
+
+// score rates how well a color suits a role, given its weight relative to the
+// most dominant color of the palette.
+func (t roleTarget) score(c hcl, relativeWeight float64) float64 {
+	return roleChromaWeight*(1-math.Abs(c.c-t.targetC)) +
+		roleLightnessWeight*(1-math.Abs(c.l-t.targetL)) +
+		roleWeightWeight*relativeWeight
+}
+
+// synthesize derives a color for the role from the given color, by moving its
+// lightness to the target and its chroma within bounds, keeping its hue.
+func (t roleTarget) synthesize(c hcl) hcl {
+	chroma := math.Max(t.minC, math.Min(c.c, t.maxC))
+	return clipChroma(hcl{c.h, chroma, t.targetL})
+}
+
+// A RoleColor is the color that plays a Role in a theme.
+type RoleColor struct {
+	Role Role
+	// Entry is the palette entry that plays the role. Synthesized colors have
+	// no weight.
+	Entry Entry
+	// Synthesized is true if no palette entry suited the role, so that its
+	// color was derived from another role.
+	Synthesized bool
+}
+
+// Roles assigns a color to each of AllRoles, in order, by scoring the entries
+// of a Palette by how close their HCL chroma and lightness are to the ideal for
+// the role, and by their weight. Each entry plays at most one role.
+//
+// Roles that no entry suits are filled with a synthesized color, derived from
+// the color of another role, preferably of the same kind, vibrant or muted, by
+// adjusting its lightness and chroma. Empty palettes have no roles.
+func (p *Palette) Roles() []RoleColor {
+	entries := p.EntriesBy(ByWeightDescending)
+	if len(entries) == 0 {
+		return nil
+	}
+	maxWeight := entries[0].Weight
+
+	roles := make([]RoleColor, len(AllRoles))
+	used := make(map[rgbaKey]bool)
+	var missing []int
+	for i, role := range AllRoles {
+		target := roleTargets[role]
+		best, bestScore := -1, math.Inf(-1)
+		for j, entry := range entries {
+			c := entryHCL(entry)
+			if used[asKey(entry.Color)] || !target.accepts(c) {
+				continue
+			}
+			relativeWeight := 0.0
+			if maxWeight > 0 {
+				relativeWeight = entry.Weight / maxWeight
+			}
+			if score := target.score(c, relativeWeight); score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best == -1 {
+			missing = append(missing, i)
+			continue
+		}
+		used[asKey(entries[best].Color)] = true
+		roles[i] = RoleColor{Role: role, Entry: entries[best]}
+	}
+
+	for _, i := range missing {
+		role := AllRoles[i]
+		base, ok := synthesisBase(role, roles)
+		if !ok {
+			// No entry suits any role, so start from the most dominant.
+			base = entryHCL(entries[0])
+		}
+		c := roleTargets[role].synthesize(base)
+		entry := Entry{Color: c}
+		if p.dictionary != nil {
+			entry.Name, _ = p.dictionary.Nearest(c)
+		}
+		roles[i] = RoleColor{Role: role, Entry: entry, Synthesized: true}
+	}
+	return roles
+}
+
+// synthesisBase picks the color, among the roles assigned from palette
+// entries, from which to synthesize a color for a missing role: the first
+// found among roles of the same kind, then among the others.
+func synthesisBase(role Role, roles []RoleColor) (hcl, bool) {
+	for _, sameKind := range []bool{true, false} {
+		for _, candidate := range roles {
+			if candidate.Entry.Color == nil || candidate.Synthesized {
+				continue
+			}
+			if (candidate.Role.vibrant() == role.vibrant()) == sameKind {
+				return entryHCL(candidate.Entry), true
+			}
+		}
+	}
+	return hcl{}, false
+}
+
+// A Theme is a set of user interface colors derived from the Roles of a
+// Palette, whose foreground colors meet the WCAG 2.x contrast ratios for text
+// against the background.
+type Theme struct {
+	// Dark is true for dark themes, with light text on a dark background.
+	Dark bool
+
+	Background color.Color
+	// Surface is the color of cards, sheets and menus over the background.
+	Surface color.Color
+	// Text is the color of body text over the background and the surface.
+	Text color.Color
+	// Primary is the color of prominent components, e.g. buttons, and meets
+	// ContrastAALarge against the background.
+	Primary color.Color
+	// OnPrimary is the color of text over the primary color.
+	OnPrimary color.Color
+	// Secondary is the color of less prominent components, and meets
+	// ContrastAALarge against the background.
+	Secondary color.Color
+	// OnSecondary is the color of text over the secondary color.
+	OnSecondary color.Color
+}
+
+// LightTheme derives a theme with dark text on a light background, tinted
+// with the light muted color of a Palette, and with its vibrant and muted
+// colors as the primary and secondary colors. Empty palettes yield a grey
+// theme.
+func (p *Palette) LightTheme() Theme {
+	return newTheme(p.Roles(), false)
+}
+
+// DarkTheme derives a theme with light text on a dark background, tinted with
+// the dark muted color of a Palette, and with its light vibrant and light muted
+// colors as the primary and secondary colors. Empty palettes yield a grey
+// theme.
+func (p *Palette) DarkTheme() Theme {
+	return newTheme(p.Roles(), true)
+}
+
+func newTheme(roles []RoleColor, dark bool) Theme {
+	role := func(r Role) hcl {
+		for _, candidate := range roles {
+			if candidate.Role == r {
+				return entryHCL(candidate.Entry)
+			}
+		}
+		return hcl{l: 0.5}
+	}
+	tint, primary, secondary := role(LightMuted), role(Vibrant), role(Muted)
+	backgroundL, surfaceL := 0.98, 0.94
+	if dark {
+		tint, primary, secondary = role(DarkMuted), role(LightVibrant), role(LightMuted)
+		backgroundL, surfaceL = 0.1, 0.16
+	}
+
+	// Only a hint of the tint is kept, so that it doesn't get in the way of
+	// the content.
+	background := clipChroma(hcl{tint.h, math.Min(tint.c, 0.04), backgroundL})
+	surface := clipChroma(hcl{tint.h, math.Min(tint.c, 0.06), surfaceL})
+	theme := Theme{
+		Dark:       dark,
+		Background: background,
+		Surface:    surface,
+	}
+	theme.Text, _ = AccessibleColor(tint, surface, ContrastAAA)
+	theme.Primary, _ = AccessibleColor(primary, background, ContrastAALarge)
+	theme.OnPrimary = Entry{Color: theme.Primary}.TextColor(ContrastAA)
+	theme.Secondary, _ = AccessibleColor(secondary, background, ContrastAALarge)
+	theme.OnSecondary = Entry{Color: theme.Secondary}.TextColor(ContrastAA)
+	return theme
+}
+
+// colors returns the colors of a theme along with their names, in order.
+func (t Theme) colors() ([]string, []color.Color) {
+	return []string{"background", "surface", "text", "primary", "on-primary", "secondary", "on-secondary"},
+		[]color.Color{t.Background, t.Surface, t.Text, t.Primary, t.OnPrimary, t.Secondary, t.OnSecondary}
+}
+
+// EncodeThemeCSS writes the colors of the given roles, and of a light and a
+// dark theme, as CSS custom properties, e.g. "--vibrant: #2c487c;" and
+// "--background: #f9f9fb;". The properties of the dark theme take over when
+// the user prefers a dark color scheme.
+func EncodeThemeCSS(w io.Writer, roles []RoleColor, light, dark Theme) error {
+	bw := bufio.NewWriter(w)
+	writeTheme := func(indent string, t Theme) {
+		names, colors := t.colors()
+		for i, name := range names {
+			fmt.Fprintf(bw, "%s--%s: %s;\n", indent, name, Entry{Color: colors[i]}.Hex())
+		}
+	}
+
+	bw.WriteString(":root {\n")
+	for _, role := range roles {
+		comment := weightComment(role.Entry)
+		if role.Synthesized {
+			comment = "synthesized"
+		}
+		fmt.Fprintf(bw, "  --%s: %s; /* %s */\n", role.Role, role.Entry.Hex(), comment)
+	}
+	writeTheme("  ", light)
+	bw.WriteString("}\n\n@media (prefers-color-scheme: dark) {\n  :root {\n")
+	writeTheme("    ", dark)
+	bw.WriteString("  }\n}\n")
+	return bw.Flush()
+}
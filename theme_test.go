@@ -0,0 +1,92 @@
+package palettor
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func themePalette() *Palette {
+	palette := &Palette{dictionary: DictionaryCSS}
+	palette.add(red, 0.2)
+	palette.add(forceHCL(color.RGBA{70, 130, 180, 255}), 0.3)
+	palette.add(white, 0.4)
+	palette.add(black, 0.1)
+	return palette
+}
+
+func TestRoles(t *testing.T) {
+	roles := themePalette().Roles()
+	assert.Len(t, roles, len(AllRoles))
+	assigned := make(map[Role]RoleColor)
+	for i, role := range roles {
+		assert.Equal(t, AllRoles[i], role.Role)
+		assigned[role.Role] = role
+	}
+
+	assert.Equal(t, red, assigned[Vibrant].Entry.Color)
+	assert.Equal(t, "steelblue", assigned[Muted].Entry.Name)
+	assert.Equal(t, white, assigned[LightMuted].Entry.Color)
+	assert.Equal(t, black, assigned[DarkMuted].Entry.Color)
+	for _, role := range []Role{Vibrant, Muted, LightMuted, DarkMuted} {
+		assert.False(t, assigned[role].Synthesized, role.String())
+		assert.True(t, assigned[role].Entry.Weight > 0, role.String())
+	}
+
+	// The missing vibrant roles are synthesized from red
+	for _, role := range []Role{LightVibrant, DarkVibrant} {
+		synthesized := assigned[role]
+		assert.True(t, synthesized.Synthesized, role.String())
+		assert.Equal(t, 0.0, synthesized.Entry.Weight)
+		assert.NotEmpty(t, synthesized.Entry.Name)
+		c := entryHCL(synthesized.Entry)
+		assert.InDelta(t, roleTargets[role].targetL, c.l, 0.01, role.String())
+		assert.True(t, c.c >= roleTargets[role].minC, role.String())
+		assert.True(t, c.colorful().IsValid(), "synthesized colors are within the sRGB gamut")
+	}
+	assert.True(t, entryHCL(assigned[LightVibrant].Entry).l > entryHCL(assigned[DarkVibrant].Entry).l)
+	assert.InDelta(t, red.h, entryHCL(assigned[DarkVibrant].Entry).h, 5)
+
+	assert.Nil(t, (&Palette{}).Roles())
+}
+
+func TestRolesAllGrey(t *testing.T) {
+	// With no vibrant entries, vibrant roles are synthesized from muted ones
+	palette := &Palette{}
+	palette.add(forceHCL(color.RGBA{128, 128, 140, 255}), 1)
+	roles := palette.Roles()
+	assert.Len(t, roles, len(AllRoles))
+	for _, role := range roles {
+		assert.NotNil(t, role.Entry.Color, role.Role.String())
+		assert.Equal(t, role.Role != Muted, role.Synthesized, role.Role.String())
+	}
+}
+
+func TestThemes(t *testing.T) {
+	palette := themePalette()
+	light, dark := palette.LightTheme(), palette.DarkTheme()
+	assert.False(t, light.Dark)
+	assert.True(t, dark.Dark)
+	assert.True(t, relativeLuminance(light.Background) > relativeLuminance(dark.Background))
+
+	for _, theme := range []Theme{light, dark, (&Palette{}).LightTheme(), (&Palette{}).DarkTheme()} {
+		assert.True(t, ContrastRatio(theme.Text, theme.Background) >= ContrastAAA)
+		assert.True(t, ContrastRatio(theme.Text, theme.Surface) >= ContrastAAA)
+		assert.True(t, ContrastRatio(theme.Primary, theme.Background) >= ContrastAALarge)
+		assert.True(t, ContrastRatio(theme.Secondary, theme.Background) >= ContrastAALarge)
+		assert.True(t, ContrastRatio(theme.OnPrimary, theme.Primary) >= ContrastAA)
+		assert.True(t, ContrastRatio(theme.OnSecondary, theme.Secondary) >= ContrastAA)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeThemeCSS(&buf, palette.Roles(), light, dark))
+	css := buf.String()
+	assert.True(t, strings.HasPrefix(css, ":root {\n  --vibrant: #ff0000; /* 20.00% */\n"), css)
+	assert.Contains(t, css, "--light-vibrant: ")
+	assert.Contains(t, css, "/* synthesized */")
+	assert.Contains(t, css, "  --background: "+Entry{Color: light.Background}.Hex()+";\n")
+	assert.Contains(t, css, "@media (prefers-color-scheme: dark) {\n  :root {\n    --background: "+Entry{Color: dark.Background}.Hex()+";\n")
+}
@@ -0,0 +1,142 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// A WeightFunc returns the relative importance of the pixel at (x, y), whose
+// color is c. Weights must not be negative; pixels with zero weight are
+// ignored entirely.
+type WeightFunc func(x, y int, c color.Color) float64
+
+// A weighter builds a WeightFunc for the given region of an image.
+type weighter func(img image.Image, bounds image.Rectangle) WeightFunc
+
+// centerWeighter builds a WeightFunc implementing a Gaussian bias towards the
+// center of the region, with a standard deviation of sigma times the width and
+// height of the region.
+func centerWeighter(sigma float64) weighter {
+	return func(img image.Image, bounds image.Rectangle) WeightFunc {
+		cx := float64(bounds.Min.X+bounds.Max.X-1) / 2
+		cy := float64(bounds.Min.Y+bounds.Max.Y-1) / 2
+		sx := sigma * float64(bounds.Dx())
+		sy := sigma * float64(bounds.Dy())
+		return func(x, y int, _ color.Color) float64 {
+			dx := (float64(x) - cx) / sx
+			dy := (float64(y) - cy) / sy
+			return math.Exp(-(dx*dx + dy*dy) / 2)
+		}
+	}
+}
+
+// saliencyFloor is the weight given to pixels in perfectly flat areas by the
+// saliency weighting, so that they still count for something.
+const saliencyFloor = 0.1
+
+// saliencyWeighter builds a WeightFunc that estimates the visual saliency of
+// each pixel from local contrast: the Sobel gradient magnitude of the
+// luminance, smoothed with a box blur so that the interiors of detailed
+// objects are favored along with their edges, and normalized to [0, 1].
+func saliencyWeighter(img image.Image, bounds image.Rectangle) WeightFunc {
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return func(int, int, color.Color) float64 { return 1 }
+	}
+
+	luma := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			luma[y*w+x] = (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+		}
+	}
+	at := func(x, y int) float64 {
+		// Clamp to the edges of the region
+		if x < 0 {
+			x = 0
+		} else if x >= w {
+			x = w - 1
+		}
+		if y < 0 {
+			y = 0
+		} else if y >= h {
+			y = h - 1
+		}
+		return luma[y*w+x]
+	}
+
+	gradient := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) -
+				at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) -
+				at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			gradient[y*w+x] = math.Sqrt(gx*gx + gy*gy)
+		}
+	}
+
+	radius := w
+	if h < radius {
+		radius = h
+	}
+	radius /= 32
+	if radius < 1 {
+		radius = 1
+	}
+	saliency := boxBlur(gradient, w, h, radius)
+
+	var max float64
+	for _, s := range saliency {
+		if s > max {
+			max = s
+		}
+	}
+	return func(x, y int, _ color.Color) float64 {
+		x, y = x-bounds.Min.X, y-bounds.Min.Y
+		if max == 0 || x < 0 || y < 0 || x >= w || y >= h {
+			return 1
+		}
+		return saliencyFloor + (1-saliencyFloor)*saliency[y*w+x]/max
+	}
+}
+
+// boxBlur averages each value in a w*h grid with its neighbors within the
+// given radius, using a summed-area table.
+func boxBlur(values []float64, w, h, radius int) []float64 {
+	sums := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var row float64
+		for x := 0; x < w; x++ {
+			row += values[y*w+x]
+			sums[(y+1)*(w+1)+x+1] = sums[y*(w+1)+x+1] + row
+		}
+	}
+
+	blurred := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := maxInt(y-radius, 0), minInt(y+radius+1, h)
+		for x := 0; x < w; x++ {
+			x0, x1 := maxInt(x-radius, 0), minInt(x+radius+1, w)
+			sum := sums[y1*(w+1)+x1] - sums[y0*(w+1)+x1] - sums[y1*(w+1)+x0] + sums[y0*(w+1)+x0]
+			blurred[y*w+x] = sum / float64((x1-x0)*(y1-y0))
+		}
+	}
+	return blurred
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
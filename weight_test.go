@@ -0,0 +1,112 @@
+package palettor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractWithWeightFunc(t *testing.T) {
+	img := halvesImage()
+
+	// Only one red and one blue pixel carry any weight, so that
+	// initialization always picks one centroid of each color.
+	weightFunc := func(x, y int, c color.Color) float64 {
+		switch {
+		case x == 0 && y == 0:
+			return 3
+		case x == 3 && y == 1:
+			return 1
+		}
+		return 0
+	}
+
+	palette, err := Extract(2, 100, img, WithWeightFunc(weightFunc))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.75, palette.Weight(red), 0.0001, "pixel weights should flow into entry weights")
+	assert.InDelta(t, 0.25, palette.Weight(blue), 0.0001, "pixel weights should flow into entry weights")
+
+	// Weights from multiple options are multiplied together
+	mask := image.NewAlpha(img.Bounds())
+	draw.Draw(mask, mask.Bounds(), image.Opaque, image.Point{}, draw.Src)
+	mask.SetAlpha(0, 0, color.Alpha{0})
+	palette, err = Extract(1, 100, img, WithWeightFunc(weightFunc), WithMask(mask))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, palette.Weight(blue))
+}
+
+func TestCenterWeighting(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 9, 9))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	cfg := newConfig([]Option{WithCenterWeighting(0.25)})
+	_, weights, err := getColors(img, cfg)
+	assert.NoError(t, err)
+	assert.Len(t, weights, 81)
+
+	center := weights[4*9+4]
+	assert.InDelta(t, 1, center, 0.0001, "center pixel should have full weight")
+	assert.Less(t, weights[0], center/10, "corner pixels should have little weight")
+	assert.InDelta(t, weights[0], weights[80], 0.0001, "weighting should be symmetric")
+	assert.InDelta(t, weights[4*9], weights[4], 0.0001, "weighting should be symmetric")
+
+	// The bias is relative to the region, not the whole image
+	cfg = newConfig([]Option{WithCenterWeighting(0.25), WithRegion(image.Rect(0, 0, 3, 3))})
+	_, weights, err = getColors(img, cfg)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1, weights[4], 0.0001, "center of region should have full weight")
+
+	// A degenerate Gaussian would weight every pixel but the center at 0
+	for _, sigma := range []float64{0, -0.25, math.NaN()} {
+		_, err = Extract(1, 10, img, WithCenterWeighting(sigma))
+		assert.Error(t, err, "sigma %v", sigma)
+	}
+}
+
+func TestSaliencyWeighting(t *testing.T) {
+	// A flat grey image with a high-contrast checkerboard of 4x4 squares in one
+	// corner
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{128, 128, 128, 255}}, image.Point{}, draw.Src)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.Set(x, y, color.Black)
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+
+	cfg := newConfig([]Option{WithSaliencyWeighting()})
+	_, weights, err := getColors(img, cfg)
+	assert.NoError(t, err)
+
+	textured := weights[8*64+8]
+	flat := weights[48*64+48]
+	assert.InDelta(t, saliencyFloor, flat, 0.0001, "flat areas should get the minimum weight")
+	assert.Greater(t, textured, 0.5, "textured areas should get more weight")
+
+	// A perfectly flat image weights every pixel equally
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	_, weights, err = getColors(img, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, weights[0])
+	assert.Equal(t, 1.0, weights[len(weights)-1])
+}
+
+func TestBoxBlur(t *testing.T) {
+	values := []float64{
+		0, 0, 0,
+		0, 9, 0,
+		0, 0, 0,
+	}
+	blurred := boxBlur(values, 3, 3, 1)
+	assert.InDelta(t, 1, blurred[4], 0.0001)
+	assert.InDelta(t, 9.0/4, blurred[0], 0.0001)
+	assert.InDelta(t, 9.0/6, blurred[1], 0.0001)
+}